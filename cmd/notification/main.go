@@ -3,22 +3,33 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
-	"github.com/IBM/sarama"
 	"github.com/demo/rolldice/config"
+	rollevents "github.com/demo/rolldice/internal/events"
 	"github.com/demo/rolldice/internal/notification/events"
 	"github.com/demo/rolldice/internal/notification/events/handlers"
 	"github.com/demo/rolldice/internal/notification/services"
+	"github.com/demo/rolldice/pkg/app"
+	"github.com/demo/rolldice/pkg/health"
 	"github.com/demo/rolldice/pkg/httpclient"
 	"github.com/demo/rolldice/pkg/logger"
 	"github.com/demo/rolldice/pkg/messaging/kafka"
+	"github.com/demo/rolldice/pkg/messaging/serde"
+	"github.com/demo/rolldice/pkg/middlewares"
 	"github.com/demo/rolldice/pkg/o11y"
-	"github.com/dnwe/otelsarama"
+	"github.com/labstack/echo/v4"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const lineTokenCheckInterval = 5 * time.Minute
+
 func main() {
 
 	otelConfig, err := config.LoadOtelConfig()
@@ -30,50 +41,142 @@ func main() {
 
 	otelservice := o11y.InitOTel(otelConfig)
 
-	defer otelservice.Shutdown()
+	application := app.New("notification")
+
+	application.Append(app.Component{Name: "opentelemetry", Stop: otelservice.Shutdown})
 
 	tracer := otel.Tracer("main")
 	logger := logger.NewLogger(otelservice.LoggerProvider)
 
-	kafkaBroker := os.Getenv("KAFKA_BROKERS")
-	kafkaUsername := os.Getenv("KAFKA_USERNAME")
-	kafkaPassword := os.Getenv("KAFKA_PASSWORD")
-	lineBotApiAuthToken := os.Getenv("LINE_BOT_API_AUTH_TOKEN")
+	kafkaConfig, err := config.LoadKafkaConfig()
 
-	brokers := []string{kafkaBroker}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lineBotApiAuthToken := os.Getenv("LINE_BOT_API_AUTH_TOKEN")
 
 	httpClient := httpclient.NewClient(tracer)
 	lineService := services.NewLineService(tracer, httpClient, lineBotApiAuthToken)
 
 	eventHandler := handlers.RollDiceResultEventHandler(lineService, logger, tracer)
+	sessionEventHandler := handlers.SessionEventHandler(lineService, logger, tracer)
+
+	eventsConfig, err := config.LoadEventsConfig()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	schemaRegistry, err := serde.NewFileRegistry(eventsConfig.SchemaRegistryPath)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rollEventCodecs, err := rollevents.NewRollEventCodecs()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Fails when the latest RollEvent schema published is one this version cannot read
+	rollEventDeserializer, err := serde.NewDeserializer(context.Background(), schemaRegistry, rollevents.RollEventSubject, rollEventCodecs...)
+
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	log.Println("Notification service is starting...")
 
-	if err := kafka.StartConsumption(
-		brokers,
-		[]string{"poc.rolldice"},
+	consumer, err := kafka.NewConsumer(
+		*kafkaConfig,
+		[]string{"poc.rolldice", "poc.rolldice.session"},
 		"poc-project",
 		"poc-group",
-		kafkaUsername,
-		kafkaPassword,
-		func(message *sarama.ConsumerMessage) error {
-			log.Printf("Message claimed: value = %s, timestamp = %v, topic = %s", string(message.Value), message.Timestamp, message.Topic)
+		kafka.EventHandler(tracer, func(ctx context.Context, event *kafka.Event) error {
+			log.Printf("Event claimed: id = %s, type = %s, timestamp = %v, topic = %s", event.ID, event.Type, event.Message.Timestamp, event.Message.Topic)
 
-			ctx := otel.GetTextMapPropagator().Extract(context.Background(), otelsarama.NewConsumerMessageCarrier(message))
+			// Messages published without an envelope have no type, their topic tells what they hold
+			if strings.HasPrefix(event.Type, rollevents.EventTypePrefix+"session.") || (event.Type == "" && event.Message.Topic == "poc.rolldice.session") {
+				var sessionEvent events.SessionEvent
+				if err := json.Unmarshal(event.Data, &sessionEvent); err != nil {
+					return skipEvent(ctx, event, err)
+				}
 
-			var rolledEvent events.RollEvent
-			if err := json.Unmarshal(message.Value, &rolledEvent); err != nil {
-				log.Fatal(err)
+				return sessionEventHandler.Handle(ctx, &sessionEvent)
 			}
 
-			if err := eventHandler.Handle(ctx, &rolledEvent); err != nil {
-				log.Fatal(err)
+			rolledEvent, err := rollevents.DecodeRollEvent(ctx, rollEventDeserializer, event.Data)
+			if err != nil {
+				return skipEvent(ctx, event, err)
 			}
 
+			return eventHandler.Handle(ctx, rolledEvent)
+		}),
+	)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application.Append(app.Component{
+		Name: "kafka consumer",
+		Start: func(context.Context) error {
+			application.Go("kafka consumer", consumer.Consume)
+			return nil
+		},
+		// Stop lets the message being handled finish before leaving the group
+		Stop: consumer.Stop,
+	})
+
+	kafkaChecker, err := kafka.NewMetadataChecker(*kafkaConfig)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	checker := health.NewChecker(health.DefaultCheckTimeout)
+	checker.Add("kafka", kafkaChecker.Check)
+	checker.Add("kafka_consumer_group", consumer.CheckJoined)
+	checker.Add("otlp", o11y.EndpointCheck(otelConfig.OtlpEndpoint))
+	// The LINE API is rate limited, probes every few seconds would eat into the push quota
+	checker.Add("line", health.Cached(lineService.VerifyToken, lineTokenCheckInterval))
+
+	e := echo.New()
+	e.HTTPErrorHandler = middlewares.HTTPErrorHandler
+
+	health.InitHealthHandler(e, checker)
+
+	port := os.Getenv("NOTIFICATION_PORT")
+	if port == "" {
+		port = "8084"
+	}
+
+	application.Append(app.Component{
+		Name: "http server",
+		Start: func(context.Context) error {
+			application.Go("http server", func() error {
+				if err := e.Start(":" + port); !errors.Is(err, http.ErrServerClosed) {
+					return err
+				}
+				return nil
+			})
 			return nil
 		},
-	); err != nil {
+		Stop: func(ctx context.Context) error {
+			return errors.Join(e.Shutdown(ctx), kafkaChecker.Close())
+		},
+	})
+
+	if err := application.Run(context.Background()); err != nil {
 		log.Fatal(err)
 	}
+}
 
+// skipEvent records why an undecodable event is dropped, handling it again would fail the same way
+func skipEvent(ctx context.Context, event *kafka.Event, err error) error {
+	trace.SpanFromContext(ctx).RecordError(err)
+	log.Printf("Skipping undecodable event: id = %s, topic = %s, partition = %d, offset = %d: %v", event.ID, event.Message.Topic, event.Message.Partition, event.Message.Offset, err)
+	return nil
 }
@@ -1,18 +1,37 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net"
 	"os"
 
 	"github.com/demo/rolldice/config"
+	"github.com/demo/rolldice/internal/events"
 	"github.com/demo/rolldice/internal/rolldice/api"
+	"github.com/demo/rolldice/internal/rolldice/auth"
+	"github.com/demo/rolldice/internal/rolldice/random"
+	"github.com/demo/rolldice/internal/rolldice/repositories"
+	"github.com/demo/rolldice/internal/rolldice/rpc"
 	"github.com/demo/rolldice/internal/rolldice/services"
+	"github.com/demo/rolldice/pkg/app"
+	"github.com/demo/rolldice/pkg/database"
+	"github.com/demo/rolldice/pkg/health"
+	"github.com/demo/rolldice/pkg/httpserver"
+	"github.com/demo/rolldice/pkg/idgen"
 	"github.com/demo/rolldice/pkg/logger"
 	"github.com/demo/rolldice/pkg/messaging/kafka"
+	"github.com/demo/rolldice/pkg/messaging/serde"
 	"github.com/demo/rolldice/pkg/middlewares"
 	"github.com/demo/rolldice/pkg/o11y"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 func main() {
@@ -24,31 +43,427 @@ func main() {
 		log.Fatalf(err.Error())
 	}
 
+	rolldiceConfig, err := config.LoadRolldiceConfig()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	serverConfig, err := config.LoadServerConfig()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	otelservice := o11y.InitOTel(otelConfig)
 
 	logger := logger.NewLogger(otelservice.LoggerProvider)
 
-	defer otelservice.Shutdown()
+	// Components stop in reverse order, OpenTelemetry goes last to flush what the others report while stopping
+	application := app.New("rolldice")
+
+	application.Append(app.Component{Name: "opentelemetry", Stop: otelservice.Shutdown})
 
 	tracer := otel.Tracer("main")
 
+	e.HTTPErrorHandler = middlewares.HTTPErrorHandler
+
+	// Rate limits key anonymous callers by IP, forwarding headers are only believed from TRUSTED_PROXIES
+	e.IPExtractor = middlewares.IPExtractor(rolldiceConfig.TrustedProxies)
+
 	e.Use(middlewares.OtelMiddleware(otelConfig.AppName))
 
-	kafkaUsername := os.Getenv("KAFKA_USERNAME")
-	kafkaPassword := os.Getenv("KAFKA_PASSWORD")
-	kafkaBroker := os.Getenv("KAFKA_BROKERS")
+	e.Use(middleware.BodyLimit(serverConfig.BodyLimit))
+
+	authenticator, err := auth.NewAuthenticator(rolldiceConfig.APIKeys, rolldiceConfig.JWKSPath, rolldiceConfig.JWTIssuer, rolldiceConfig.JWTAudience)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !authenticator.Enabled() {
+		log.Println("Authentication is disabled, set API_KEYS or JWKS_PATH to require callers to authenticate")
+	}
+
+	rateLimitSkipper := func(c echo.Context) bool {
+		return c.Path() == health.LivenessPath || c.Path() == health.ReadinessPath
+	}
+
+	// Runs before Authentication, which rejects anonymous callers and bad credentials without reaching the limiter below
+	ipRateLimiter, err := middlewares.RateLimiter(middlewares.RateLimitConfig{
+		Skipper: rateLimitSkipper,
+		Default: middlewares.RateLimit(rolldiceConfig.RateLimitIP),
+		Route:   api.UnversionedRoute,
+	}, otel.Meter("main"))
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	e.Use(ipRateLimiter)
+
+	e.Use(api.Authentication(authenticator))
+
+	rateLimitRoutes := map[string]middlewares.RateLimit{}
+	for route, limit := range rolldiceConfig.RateLimitRoutes {
+		rateLimitRoutes[route] = middlewares.RateLimit(limit)
+	}
+
+	rateLimiter, err := middlewares.RateLimiter(middlewares.RateLimitConfig{
+		Skipper:    rateLimitSkipper,
+		Default:    middlewares.RateLimit(rolldiceConfig.RateLimit),
+		Routes:     rateLimitRoutes,
+		Route:      api.UnversionedRoute,
+		Extractors: []middlewares.KeyExtractor{api.KeyBySubject},
+	}, otel.Meter("main"))
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	e.Use(rateLimiter)
+
+	openAPI, err := api.LoadOpenAPI()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	openAPIValidator, err := middlewares.OpenAPIValidator(openAPI, rolldiceConfig.ValidateResponses)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	e.Use(openAPIValidator)
+
+	kafkaConfig, err := config.LoadKafkaConfig()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	kafkaTransactionalID := os.Getenv("KAFKA_TRANSACTIONAL_ID")
+
+	idGenerator, err := idgen.NewGenerator(rolldiceConfig.IDGenerator, rolldiceConfig.NodeID)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	eventsConfig, err := config.LoadEventsConfig()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	producerOptions := []kafka.ProducerOption{}
+	if eventsConfig.CloudEventsMode != "" {
+		producerOptions = append(producerOptions, kafka.WithCloudEvents(eventsConfig.CloudEventsMode, eventsConfig.CloudEventsSource))
+	}
+	if rolldiceConfig.KafkaIdempotent {
+		producerOptions = append(producerOptions, kafka.WithIdempotence())
+	}
+	if kafkaTransactionalID != "" {
+		producerOptions = append(producerOptions, kafka.WithTransactionalID(kafkaTransactionalID))
+	}
+	if rolldiceConfig.KafkaAsync {
+		producerOptions = append(producerOptions, kafka.WithAsync(kafka.AsyncConfig{
+			Linger:      rolldiceConfig.KafkaLinger,
+			BatchSize:   rolldiceConfig.KafkaBatchSize,
+			Compression: rolldiceConfig.KafkaCompression,
+			MaxInFlight: rolldiceConfig.KafkaMaxInFlight,
+		}))
+	}
+
+	kafkaProducer, err := kafka.NewKafkaProducer(*kafkaConfig, idGenerator, logger, tracer, producerOptions...)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application.Append(app.Component{
+		Name: "kafka producer",
+		Stop: func(context.Context) error { return kafkaProducer.Close() },
+	})
+
+	randomSource, err := random.NewRandomSource(rolldiceConfig.RandomSource, rolldiceConfig.RandomSeed, rolldiceConfig.RandomScript)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := database.OpenSQLite(rolldiceConfig.DatabasePath, tracer)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application.Append(app.Component{
+		Name: "sqlite",
+		Stop: func(context.Context) error { return db.Close() },
+	})
+
+	rollRepository, err := repositories.NewSQLiteRollRepository(db)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	seedRepository, err := repositories.NewSQLiteSeedRepository(db)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fairnessService := services.NewFairnessService(tracer, logger, seedRepository, idGenerator)
+
+	streamService, err := services.NewStreamService(tracer, logger, otel.Meter("main"), rollRepository)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	outboxRepository, err := repositories.NewSQLiteOutboxRepository(db)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	outboxRelay, err := services.NewOutboxRelay(tracer, logger, otel.Meter("main"), outboxRepository, kafkaProducer, rolldiceConfig.OutboxInterval, rolldiceConfig.OutboxBatchSize, rolldiceConfig.OutboxRetention)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Stopped before the Kafka producer and SQLite it relays between
+	application.Append(app.Component{
+		Name: "outbox relay",
+		Start: func(context.Context) error {
+			application.Go("outbox relay", outboxRelay.Run)
+			return nil
+		},
+		Stop: outboxRelay.Stop,
+	})
+
+	schemaRegistry, err := serde.NewFileRegistry(eventsConfig.SchemaRegistryPath)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rollEventCodec, err := events.NewRollEventCodec(eventsConfig.Format)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Fails on a RollEvent schema the consumers of the previous version could not read
+	rollEventSerializer, err := serde.NewSerializer(context.Background(), schemaRegistry, events.RollEventSubject, rollEventCodec)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rolldiceService := services.NewRollDiceService(tracer, logger, randomSource, rollRepository, idGenerator, fairnessService, streamService, outboxRelay, rollEventSerializer)
+
+	idempotencyRepository, err := repositories.NewSQLiteIdempotencyRepository(db)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	idempotencyService := services.NewIdempotencyService(tracer, logger, idempotencyRepository, rollRepository, rolldiceConfig.IdempotencyTTL)
+
+	versions := []api.APIVersion{}
+	for _, name := range []string{"v1", "v2"} {
+		versions = append(versions, api.APIVersion{
+			Name:        name,
+			Deprecation: rolldiceConfig.APIDeprecations[name],
+			Sunset:      rolldiceConfig.APISunsets[name],
+		})
+	}
+
+	router := api.NewRouter(e, versions...)
+
+	api.InitOpenAPIHandler(e)
+	api.InitRolldiceHandler(router, rolldiceService, idempotencyService)
+	api.InitFairnessHandler(router, fairnessService, rolldiceService, api.Admin(authenticator, rolldiceConfig.AdminSubjects))
+	api.InitStreamHandler(router, streamService, rolldiceConfig.StreamHeartbeat, rolldiceConfig.AllowedOrigins)
+
+	statsService, err := services.NewStatsService(tracer, logger, otel.Meter("main"), rollRepository, rolldiceConfig.StatsMetricsWindow)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	api.InitStatsHandler(router, statsService)
+
+	sessionRepository, err := repositories.NewSQLiteSessionRepository(db)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sessionService := services.NewSessionService(tracer, logger, outboxRelay, sessionRepository, idGenerator, rolldiceService)
+
+	api.InitSessionHandler(router, sessionService)
+
+	kafkaChecker, err := kafka.NewMetadataChecker(*kafkaConfig)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application.Append(app.Component{
+		Name: "kafka health check",
+		Stop: func(context.Context) error { return kafkaChecker.Close() },
+	})
+
+	checker := health.NewChecker(health.DefaultCheckTimeout)
+	checker.Add("kafka", kafkaChecker.Check)
+	checker.Add("otlp", o11y.EndpointCheck(otelConfig.OtlpEndpoint))
+	checker.Add("sqlite", db.Ping)
+
+	// With ADMIN_PORT the probes skip TLS, client certificates and rate limits of the main server
+	if rolldiceConfig.AdminPort != "" {
+		admin := echo.New()
+		admin.HTTPErrorHandler = middlewares.HTTPErrorHandler
+
+		health.InitHealthHandler(admin, checker)
+
+		adminServer, err := httpserver.New(httpserver.Config{
+			Addr:              ":" + rolldiceConfig.AdminPort,
+			ReadHeaderTimeout: serverConfig.ReadHeaderTimeout,
+			WriteTimeout:      serverConfig.WriteTimeout,
+			IdleTimeout:       serverConfig.IdleTimeout,
+		}, admin)
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		application.Append(app.Component{
+			Name: "admin server",
+			Start: func(context.Context) error {
+				application.Go("admin server", func() error { return httpserver.ListenAndServe(adminServer) })
+				return nil
+			},
+			Stop: adminServer.Shutdown,
+		})
+	} else {
+		health.InitHealthHandler(e, checker)
+	}
+
+	// The gRPC port shares the TLS and client certificates of the HTTP server
+	grpcTLSConfig, err := httpserver.TLSConfig(*serverConfig)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	grpcOptions := []grpc.ServerOption{}
+	if grpcTLSConfig != nil {
+		grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(grpcTLSConfig)))
+	}
+
+	grpcServer := newGRPCServer(authenticator, rolldiceService, streamService, grpcOptions...)
+
+	grpcListener, err := net.Listen("tcp", ":"+rolldiceConfig.GRPCPort)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	application.Append(grpcComponent("grpc server", application, grpcServer, grpcListener))
 
-	brokers := []string{kafkaBroker}
+	// The REST gateway calls its own gRPC server in memory, the HTTP server already checked the TLS of its requests
+	gatewayListener := bufconn.Listen(gatewayBufferSize)
+	gatewayServer := newGRPCServer(authenticator, rolldiceService, streamService)
 
-	kafkaProducer, err := kafka.NewKafkaProducer(brokers, kafkaUsername, kafkaPassword, logger, tracer)
+	application.Append(grpcComponent("grpc gateway server", application, gatewayServer, gatewayListener))
+
+	grpcConn, err := grpc.NewClient(
+		"passthrough:///gateway",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return gatewayListener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	)
 
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	rolldiceService := services.NewRollDiceService(tracer, logger, kafkaProducer)
+	application.Append(app.Component{
+		Name: "grpc gateway connection",
+		Stop: func(context.Context) error { return grpcConn.Close() },
+	})
+
+	if err := rpc.InitGatewayHandler(context.Background(), e, grpcConn); err != nil {
+		log.Fatal(err)
+	}
+
+	server, err := httpserver.New(*serverConfig, e)
+
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	api.InitRolldiceHandler(e, rolldiceService)
+	application.Append(app.Component{
+		Name: "http server",
+		Start: func(context.Context) error {
+			application.Go("http server", func() error { return httpserver.ListenAndServe(server) })
+			return nil
+		},
+		// Shutdown stops accepting connections and waits for in-flight requests
+		Stop: server.Shutdown,
+	})
 
-	e.Logger.Fatal(e.Start(":8083"))
+	// Open roll streams never end by themselves, close them first so the servers can drain
+	application.Append(app.Component{Name: "roll streams", Stop: streamService.Close})
+
+	if err := application.Run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// gatewayBufferSize is the in-memory connection buffer between the REST gateway and its gRPC server
+const gatewayBufferSize = 1 << 20
+
+func newGRPCServer(authenticator *auth.Authenticator, rolldiceService *services.RollDiceService, streamService *services.StreamService, opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(append([]grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.UnaryInterceptor(rpc.AuthUnaryInterceptor(authenticator)),
+		grpc.StreamInterceptor(rpc.AuthStreamInterceptor(authenticator)),
+	}, opts...)...)
+
+	rpc.InitRollDiceServer(server, rolldiceService, streamService)
+
+	return server
+}
+
+// grpcComponent serves server on listener, stopping drains the open calls until the stop timeout
+func grpcComponent(name string, application *app.App, server *grpc.Server, listener net.Listener) app.Component {
+	return app.Component{
+		Name: name,
+		Start: func(context.Context) error {
+			application.Go(name, func() error { return server.Serve(listener) })
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			stopped := make(chan struct{})
+			go func() {
+				server.GracefulStop()
+				close(stopped)
+			}()
+
+			select {
+			case <-stopped:
+				return nil
+			case <-ctx.Done():
+				server.Stop()
+				return ctx.Err()
+			}
+		},
+	}
 }
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/demo/rolldice/pkg/messaging/kafka"
+	"github.com/demo/rolldice/pkg/messaging/serde"
+)
+
+type EventsConfig struct {
+	// Format is the format events are published in, consumers read every format
+	Format             serde.Format
+	SchemaRegistryPath string
+	// CloudEventsMode is empty when events are published without a CloudEvents envelope
+	CloudEventsMode   kafka.CloudEventsMode
+	CloudEventsSource string
+}
+
+func LoadEventsConfig() (*EventsConfig, error) {
+	config := &EventsConfig{
+		Format:             serde.FormatJSONSchema,
+		SchemaRegistryPath: os.Getenv("SCHEMA_REGISTRY_PATH"),
+		CloudEventsMode:    kafka.CloudEventsBinary,
+		CloudEventsSource:  os.Getenv("CLOUDEVENTS_SOURCE"),
+	}
+
+	if config.SchemaRegistryPath == "" {
+		config.SchemaRegistryPath = "schema-registry.json"
+	}
+
+	switch format := strings.ToLower(os.Getenv("EVENT_FORMAT")); format {
+	case "", "json":
+	case "avro":
+		config.Format = serde.FormatAvro
+	case "protobuf":
+		config.Format = serde.FormatProtobuf
+	default:
+		return nil, fmt.Errorf("invalid EVENT_FORMAT: expected json, avro or protobuf, got %q", format)
+	}
+
+	switch mode := strings.ToLower(os.Getenv("CLOUDEVENTS_MODE")); mode {
+	case "", string(kafka.CloudEventsBinary):
+	case string(kafka.CloudEventsStructured):
+		config.CloudEventsMode = kafka.CloudEventsStructured
+	case "none":
+		config.CloudEventsMode = ""
+	default:
+		return nil, fmt.Errorf("invalid CLOUDEVENTS_MODE: expected binary, structured or none, got %q", mode)
+	}
+
+	if config.CloudEventsSource == "" {
+		config.CloudEventsSource = "/rolldice"
+	}
+
+	return config, nil
+}
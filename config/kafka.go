@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/demo/rolldice/pkg/messaging/kafka"
+)
+
+// LoadKafkaConfig defaults to SASL/PLAIN over TLS when KAFKA_USERNAME is set, and to TLS without SASL otherwise
+func LoadKafkaConfig() (*kafka.ClientConfig, error) {
+	config := &kafka.ClientConfig{
+		SASL: kafka.SASLConfig{
+			Mechanism: os.Getenv("KAFKA_SASL_MECHANISM"),
+			Username:  os.Getenv("KAFKA_USERNAME"),
+			Password:  os.Getenv("KAFKA_PASSWORD"),
+		},
+		TLS: kafka.TLSConfig{
+			Enabled:    true,
+			CAFile:     os.Getenv("KAFKA_TLS_CA_FILE"),
+			CertFile:   os.Getenv("KAFKA_TLS_CERT_FILE"),
+			KeyFile:    os.Getenv("KAFKA_TLS_KEY_FILE"),
+			ServerName: os.Getenv("KAFKA_TLS_SERVER_NAME"),
+		},
+	}
+
+	for _, broker := range strings.Split(os.Getenv("KAFKA_BROKERS"), ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			config.Brokers = append(config.Brokers, broker)
+		}
+	}
+
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("missing KAFKA_BROKERS")
+	}
+
+	if config.SASL.Mechanism == "" && config.SASL.Username != "" {
+		config.SASL.Mechanism = kafka.SASLPlain
+	}
+
+	switch config.SASL.Mechanism {
+	case "", kafka.SASLNone, kafka.SASLPlain, kafka.SASLScramSHA256, kafka.SASLScramSHA512:
+	case kafka.SASLOAuthBearer:
+		switch tokenFile, token := os.Getenv("KAFKA_OAUTH_TOKEN_FILE"), os.Getenv("KAFKA_OAUTH_TOKEN"); {
+		case tokenFile != "":
+			config.SASL.TokenSource = kafka.FileTokenSource(tokenFile)
+		case token != "":
+			config.SASL.TokenSource = kafka.StaticTokenSource(token)
+		default:
+			return nil, fmt.Errorf("KAFKA_SASL_MECHANISM=OAUTHBEARER requires KAFKA_OAUTH_TOKEN_FILE or KAFKA_OAUTH_TOKEN")
+		}
+	default:
+		return nil, fmt.Errorf("invalid KAFKA_SASL_MECHANISM: expected none, PLAIN, SCRAM-SHA-256, SCRAM-SHA-512 or OAUTHBEARER, got %q", config.SASL.Mechanism)
+	}
+
+	if tls := os.Getenv("KAFKA_TLS"); tls != "" {
+		value, err := strconv.ParseBool(tls)
+		if err != nil {
+			return nil, fmt.Errorf("invalid KAFKA_TLS: %w", err)
+		}
+		config.TLS.Enabled = value
+	}
+
+	return config, nil
+}
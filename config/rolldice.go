@@ -0,0 +1,336 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit is written as limit/window[:burst], e.g. 10/1m or 5/1s:20
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+	Burst  int
+}
+
+type RolldiceConfig struct {
+	DatabasePath       string
+	IDGenerator        string
+	NodeID             int64
+	RandomSource       string
+	RandomSeed         int64
+	RandomScript       []int
+	StatsMetricsWindow time.Duration
+	IdempotencyTTL     time.Duration
+	StreamHeartbeat    time.Duration
+	ValidateResponses  bool
+	GRPCPort           string
+	AdminPort          string
+	APIKeys            map[string]string
+	AdminSubjects      map[string]bool
+	AllowedOrigins     map[string]bool
+	JWKSPath           string
+	JWTIssuer          string
+	JWTAudience        string
+	RateLimit          RateLimit
+	RateLimitIP        RateLimit
+	RateLimitRoutes    map[string]RateLimit
+	TrustedProxies     []*net.IPNet
+	APIDeprecations    map[string]time.Time
+	APISunsets         map[string]time.Time
+	OutboxInterval     time.Duration
+	OutboxBatchSize    int
+	OutboxRetention    time.Duration
+	KafkaIdempotent    bool
+	KafkaAsync         bool
+	KafkaLinger        time.Duration
+	KafkaBatchSize     int
+	KafkaCompression   string
+	KafkaMaxInFlight   int
+}
+
+func LoadRolldiceConfig() (*RolldiceConfig, error) {
+	config := &RolldiceConfig{
+		DatabasePath:       os.Getenv("DATABASE_PATH"),
+		IDGenerator:        os.Getenv("ID_GENERATOR"),
+		RandomSource:       os.Getenv("RANDOM_SOURCE"),
+		KafkaCompression:   os.Getenv("KAFKA_COMPRESSION"),
+		GRPCPort:           os.Getenv("GRPC_PORT"),
+		AdminPort:          os.Getenv("ADMIN_PORT"),
+		JWKSPath:           os.Getenv("JWKS_PATH"),
+		JWTIssuer:          os.Getenv("JWT_ISSUER"),
+		JWTAudience:        os.Getenv("JWT_AUDIENCE"),
+		APIKeys:            map[string]string{},
+		AdminSubjects:      map[string]bool{},
+		AllowedOrigins:     map[string]bool{},
+		RateLimitRoutes:    map[string]RateLimit{},
+		APIDeprecations:    map[string]time.Time{},
+		APISunsets:         map[string]time.Time{},
+		StatsMetricsWindow: 24 * time.Hour,
+		IdempotencyTTL:     24 * time.Hour,
+		StreamHeartbeat:    15 * time.Second,
+		OutboxInterval:     5 * time.Second,
+		OutboxBatchSize:    100,
+		OutboxRetention:    24 * time.Hour,
+		KafkaIdempotent:    true,
+	}
+
+	if config.DatabasePath == "" {
+		config.DatabasePath = "rolldice.db"
+	}
+
+	if config.GRPCPort == "" {
+		config.GRPCPort = "9090"
+	}
+
+	if nodeID := os.Getenv("NODE_ID"); nodeID != "" {
+		value, err := strconv.ParseInt(nodeID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NODE_ID: %w", err)
+		}
+		config.NodeID = value
+	}
+
+	if window := os.Getenv("STATS_METRICS_WINDOW"); window != "" {
+		value, err := time.ParseDuration(window)
+		if err != nil {
+			return nil, fmt.Errorf("invalid STATS_METRICS_WINDOW: %w", err)
+		}
+		config.StatsMetricsWindow = value
+	}
+
+	if ttl := os.Getenv("IDEMPOTENCY_TTL"); ttl != "" {
+		value, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IDEMPOTENCY_TTL: %w", err)
+		}
+		config.IdempotencyTTL = value
+	}
+
+	if heartbeat := os.Getenv("STREAM_HEARTBEAT_INTERVAL"); heartbeat != "" {
+		value, err := time.ParseDuration(heartbeat)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("invalid STREAM_HEARTBEAT_INTERVAL: %q", heartbeat)
+		}
+		config.StreamHeartbeat = value
+	}
+
+	if interval := os.Getenv("OUTBOX_POLL_INTERVAL"); interval != "" {
+		value, err := time.ParseDuration(interval)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("invalid OUTBOX_POLL_INTERVAL: %q", interval)
+		}
+		config.OutboxInterval = value
+	}
+
+	if batchSize := os.Getenv("OUTBOX_BATCH_SIZE"); batchSize != "" {
+		value, err := strconv.Atoi(batchSize)
+		if err != nil || value < 1 {
+			return nil, fmt.Errorf("invalid OUTBOX_BATCH_SIZE: %q", batchSize)
+		}
+		config.OutboxBatchSize = value
+	}
+
+	if retention := os.Getenv("OUTBOX_RETENTION"); retention != "" {
+		value, err := time.ParseDuration(retention)
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("invalid OUTBOX_RETENTION: %q", retention)
+		}
+		config.OutboxRetention = value
+	}
+
+	if idempotent := os.Getenv("KAFKA_IDEMPOTENT"); idempotent != "" {
+		value, err := strconv.ParseBool(idempotent)
+		if err != nil {
+			return nil, fmt.Errorf("invalid KAFKA_IDEMPOTENT: %w", err)
+		}
+		config.KafkaIdempotent = value
+	}
+
+	if async := os.Getenv("KAFKA_ASYNC"); async != "" {
+		value, err := strconv.ParseBool(async)
+		if err != nil {
+			return nil, fmt.Errorf("invalid KAFKA_ASYNC: %w", err)
+		}
+		config.KafkaAsync = value
+	}
+
+	if linger := os.Getenv("KAFKA_LINGER"); linger != "" {
+		value, err := time.ParseDuration(linger)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("invalid KAFKA_LINGER: %q", linger)
+		}
+		config.KafkaLinger = value
+	}
+
+	kafkaSizes := map[string]*int{
+		"KAFKA_BATCH_SIZE":    &config.KafkaBatchSize,
+		"KAFKA_MAX_IN_FLIGHT": &config.KafkaMaxInFlight,
+	}
+
+	for name, size := range kafkaSizes {
+		if value := os.Getenv(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				return nil, fmt.Errorf("invalid %s: %q", name, value)
+			}
+			*size = parsed
+		}
+	}
+
+	if validate := os.Getenv("OPENAPI_VALIDATE_RESPONSES"); validate != "" {
+		value, err := strconv.ParseBool(validate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OPENAPI_VALIDATE_RESPONSES: %w", err)
+		}
+		config.ValidateResponses = value
+	}
+
+	if apiKeys := os.Getenv("API_KEYS"); apiKeys != "" {
+		for _, pair := range strings.Split(apiKeys, ",") {
+			subject, key, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if !ok || subject == "" || key == "" {
+				return nil, fmt.Errorf("invalid API_KEYS: expected subject:key pairs")
+			}
+			config.APIKeys[subject] = key
+		}
+	}
+
+	if admins := os.Getenv("ADMIN_SUBJECTS"); admins != "" {
+		for _, subject := range strings.Split(admins, ",") {
+			if subject = strings.TrimSpace(subject); subject != "" {
+				config.AdminSubjects[subject] = true
+			}
+		}
+	}
+
+	if origins := os.Getenv("ALLOWED_ORIGINS"); origins != "" {
+		for _, origin := range strings.Split(origins, ",") {
+			if origin = strings.TrimSpace(origin); origin == "" {
+				continue
+			}
+			value, err := url.Parse(origin)
+			if err != nil || (value.Scheme != "http" && value.Scheme != "https") || value.Host == "" || strings.Trim(value.Path, "/") != "" {
+				return nil, fmt.Errorf("invalid ALLOWED_ORIGINS: %q is not a scheme://host[:port] origin", origin)
+			}
+			config.AllowedOrigins[strings.ToLower(value.Scheme+"://"+value.Host)] = true
+		}
+	}
+
+	if rateLimit := os.Getenv("RATE_LIMIT"); rateLimit != "" {
+		value, err := parseRateLimit(rateLimit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMIT: %w", err)
+		}
+		config.RateLimit = value
+	}
+
+	// Limits every client IP before authentication, so callers failing it are limited too
+	config.RateLimitIP = config.RateLimit
+	if rateLimit := os.Getenv("RATE_LIMIT_IP"); rateLimit != "" {
+		value, err := parseRateLimit(rateLimit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_IP: %w", err)
+		}
+		config.RateLimitIP = value
+	}
+
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		for _, proxy := range strings.Split(proxies, ",") {
+			_, network, err := net.ParseCIDR(strings.TrimSpace(proxy))
+			if err != nil {
+				return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+			}
+			config.TrustedProxies = append(config.TrustedProxies, network)
+		}
+	}
+
+	if routes := os.Getenv("RATE_LIMIT_ROUTES"); routes != "" {
+		for _, pair := range strings.Split(routes, ",") {
+			route, rateLimit, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid RATE_LIMIT_ROUTES: expected route=limit pairs")
+			}
+			value, err := parseRateLimit(rateLimit)
+			if err != nil {
+				return nil, fmt.Errorf("invalid RATE_LIMIT_ROUTES for %s: %w", route, err)
+			}
+			config.RateLimitRoutes[route] = value
+		}
+	}
+
+	versionDates := map[string]map[string]time.Time{
+		"API_DEPRECATIONS": config.APIDeprecations,
+		"API_SUNSETS":      config.APISunsets,
+	}
+
+	for name, dates := range versionDates {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		for _, pair := range strings.Split(value, ",") {
+			version, date, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || version == "" {
+				return nil, fmt.Errorf("invalid %s: expected version=date pairs", name)
+			}
+			at, err := time.Parse(time.RFC3339, date)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s for %s: %w", name, version, err)
+			}
+			dates[version] = at
+		}
+	}
+
+	if seed := os.Getenv("RANDOM_SEED"); seed != "" {
+		value, err := strconv.ParseInt(seed, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RANDOM_SEED: %w", err)
+		}
+		config.RandomSeed = value
+	}
+
+	if script := os.Getenv("RANDOM_SCRIPT"); script != "" {
+		for _, face := range strings.Split(script, ",") {
+			value, err := strconv.Atoi(strings.TrimSpace(face))
+			if err != nil {
+				return nil, fmt.Errorf("invalid RANDOM_SCRIPT: %w", err)
+			}
+			config.RandomScript = append(config.RandomScript, value)
+		}
+	}
+
+	return config, nil
+}
+
+func parseRateLimit(value string) (RateLimit, error) {
+	spec, burst, hasBurst := strings.Cut(value, ":")
+
+	limit, window, ok := strings.Cut(spec, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("expected limit/window[:burst], got %q", value)
+	}
+
+	rateLimit := RateLimit{}
+	var err error
+
+	if rateLimit.Limit, err = strconv.Atoi(limit); err != nil || rateLimit.Limit < 1 {
+		return RateLimit{}, fmt.Errorf("limit must be a positive integer, got %q", limit)
+	}
+
+	if rateLimit.Window, err = time.ParseDuration(window); err != nil || rateLimit.Window <= 0 {
+		return RateLimit{}, fmt.Errorf("window must be a positive duration, got %q", window)
+	}
+
+	if hasBurst {
+		if rateLimit.Burst, err = strconv.Atoi(burst); err != nil || rateLimit.Burst < 1 {
+			return RateLimit{}, fmt.Errorf("burst must be a positive integer, got %q", burst)
+		}
+	}
+
+	return rateLimit, nil
+}
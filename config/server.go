@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/demo/rolldice/pkg/httpserver"
+	"github.com/labstack/gommon/bytes"
+)
+
+func LoadServerConfig() (*httpserver.Config, error) {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8083"
+	}
+
+	config := &httpserver.Config{
+		Addr:              net.JoinHostPort(os.Getenv("HOST"), port),
+		ReadTimeout:       30 * time.Second,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		MaxHeaderBytes:    1 << 20,
+		BodyLimit:         "1M",
+		TLSCertFile:       os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:        os.Getenv("TLS_KEY_FILE"),
+		TLSClientCAFile:   os.Getenv("TLS_CLIENT_CA_FILE"),
+		TLSClientAuth:     os.Getenv("TLS_CLIENT_AUTH"),
+	}
+
+	timeouts := map[string]*time.Duration{
+		"SERVER_READ_TIMEOUT":        &config.ReadTimeout,
+		"SERVER_READ_HEADER_TIMEOUT": &config.ReadHeaderTimeout,
+		"SERVER_WRITE_TIMEOUT":       &config.WriteTimeout,
+		"SERVER_IDLE_TIMEOUT":        &config.IdleTimeout,
+	}
+
+	for name, timeout := range timeouts {
+		if value := os.Getenv(name); value != "" {
+			duration, err := time.ParseDuration(value)
+			if err != nil || duration < 0 {
+				return nil, fmt.Errorf("invalid %s: %q", name, value)
+			}
+			*timeout = duration
+		}
+	}
+
+	if maxHeaderBytes := os.Getenv("SERVER_MAX_HEADER_BYTES"); maxHeaderBytes != "" {
+		value, err := bytes.Parse(maxHeaderBytes)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("invalid SERVER_MAX_HEADER_BYTES: %q", maxHeaderBytes)
+		}
+		config.MaxHeaderBytes = int(value)
+	}
+
+	if bodyLimit := os.Getenv("SERVER_BODY_LIMIT"); bodyLimit != "" {
+		if value, err := bytes.Parse(bodyLimit); err != nil || value <= 0 {
+			return nil, fmt.Errorf("invalid SERVER_BODY_LIMIT: %q", bodyLimit)
+		}
+		config.BodyLimit = bodyLimit
+	}
+
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		return nil, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	if config.TLSClientCAFile != "" && config.TLSCertFile == "" {
+		return nil, fmt.Errorf("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+
+	if auth := config.TLSClientAuth; auth != "" && auth != httpserver.ClientAuthRequire && auth != httpserver.ClientAuthOptional {
+		return nil, fmt.Errorf("invalid TLS_CLIENT_AUTH: expected require or optional, got %q", auth)
+	}
+
+	return config, nil
+}
@@ -0,0 +1,193 @@
+package events
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/demo/rolldice/pkg/messaging/serde"
+	eventsv1 "github.com/demo/rolldice/pkg/pb/rolldice/events/v1"
+)
+
+// RollEventSubject is the registry subject of RollEvent, named after its topic as the registry's default strategy does
+const RollEventSubject = "poc.rolldice-value"
+
+var (
+	//go:embed roll_event.schema.json
+	rollEventJSONSchema string
+	//go:embed roll_event.avsc
+	rollEventAvroSchema string
+)
+
+// NewRollEventCodec encodes *RollEvent values in format
+func NewRollEventCodec(format serde.Format) (serde.Codec, error) {
+	switch format {
+	case serde.FormatJSONSchema:
+		codec, err := serde.NewJSONSchemaCodec(rollEventJSONSchema)
+		if err != nil {
+			return nil, err
+		}
+		return codec, nil
+	case serde.FormatAvro:
+		codec, err := serde.NewAvroCodec(rollEventAvroSchema)
+		if err != nil {
+			return nil, err
+		}
+		return codec, nil
+	case serde.FormatProtobuf:
+		codec, err := serde.NewProtobufCodec(&eventsv1.RollEvent{})
+		if err != nil {
+			return nil, err
+		}
+		return rollEventProtobufCodec{codec}, nil
+	default:
+		return nil, fmt.Errorf("unsupported event format %q", format)
+	}
+}
+
+// NewRollEventCodecs reads RollEvent in every format, so consumers follow a change of format on the producer
+func NewRollEventCodecs() ([]serde.Codec, error) {
+	codecs := []serde.Codec{}
+
+	for _, format := range []serde.Format{serde.FormatJSONSchema, serde.FormatAvro, serde.FormatProtobuf} {
+		codec, err := NewRollEventCodec(format)
+		if err != nil {
+			return nil, err
+		}
+		codecs = append(codecs, codec)
+	}
+
+	return codecs, nil
+}
+
+// DecodeRollEvent also reads the plain JSON events published before RollEvent had a schema
+func DecodeRollEvent(ctx context.Context, deserializer *serde.Deserializer, data []byte) (*RollEvent, error) {
+	var event RollEvent
+
+	if !serde.IsFramed(data) {
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, err
+		}
+		return &event, nil
+	}
+
+	if err := deserializer.Deserialize(ctx, data, &event); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// rollEventProtobufCodec converts RollEvent to and from its generated message
+type rollEventProtobufCodec struct {
+	*serde.ProtobufCodec
+}
+
+func (c rollEventProtobufCodec) Marshal(v any) ([]byte, error) {
+	event, ok := v.(*RollEvent)
+	if !ok {
+		return nil, fmt.Errorf("expected a *RollEvent, got %T", v)
+	}
+
+	return c.ProtobufCodec.Marshal(toProto(event))
+}
+
+func (c rollEventProtobufCodec) Unmarshal(writer serde.Schema, data []byte, v any) error {
+	event, ok := v.(*RollEvent)
+	if !ok {
+		return fmt.Errorf("expected a *RollEvent, got %T", v)
+	}
+
+	message := &eventsv1.RollEvent{}
+	if err := c.ProtobufCodec.Unmarshal(writer, data, message); err != nil {
+		return err
+	}
+
+	*event = fromProto(message)
+
+	return nil
+}
+
+func toProto(event *RollEvent) *eventsv1.RollEvent {
+	message := &eventsv1.RollEvent{
+		RollId:     event.RollID,
+		Expression: event.Expression,
+		Result:     int32(event.Result),
+		RollerId:   event.RollerID,
+		SessionId:  event.SessionID,
+		Timestamp:  event.Timestamp,
+	}
+
+	for _, term := range event.Terms {
+		protoTerm := &eventsv1.Term{
+			Notation: term.Notation,
+			Sign:     int32(term.Sign),
+			Sides:    int32(term.Sides),
+			Subtotal: int32(term.Subtotal),
+		}
+
+		for _, die := range term.Dice {
+			protoDie := &eventsv1.Die{Value: int32(die.Value), Kept: die.Kept, Exploded: die.Exploded}
+			for _, value := range die.Rerolled {
+				protoDie.Rerolled = append(protoDie.Rerolled, int32(value))
+			}
+			protoTerm.Dice = append(protoTerm.Dice, protoDie)
+		}
+
+		message.Terms = append(message.Terms, protoTerm)
+	}
+
+	if fairness := event.Fairness; fairness != nil {
+		message.Fairness = &eventsv1.Fairness{
+			ServerSeedId:   fairness.ServerSeedID,
+			ServerSeedHash: fairness.ServerSeedHash,
+			ClientSeed:     fairness.ClientSeed,
+			Nonce:          fairness.Nonce,
+		}
+	}
+
+	return message
+}
+
+func fromProto(message *eventsv1.RollEvent) RollEvent {
+	event := RollEvent{
+		RollID:     message.RollId,
+		Expression: message.Expression,
+		Terms:      []RollTerm{},
+		Result:     int(message.Result),
+		RollerID:   message.RollerId,
+		SessionID:  message.SessionId,
+		Timestamp:  message.Timestamp,
+	}
+
+	for _, protoTerm := range message.Terms {
+		term := RollTerm{
+			Notation: protoTerm.Notation,
+			Sign:     int(protoTerm.Sign),
+			Sides:    int(protoTerm.Sides),
+			Subtotal: int(protoTerm.Subtotal),
+		}
+
+		for _, protoDie := range protoTerm.Dice {
+			die := Die{Value: int(protoDie.Value), Kept: protoDie.Kept, Exploded: protoDie.Exploded}
+			for _, value := range protoDie.Rerolled {
+				die.Rerolled = append(die.Rerolled, int(value))
+			}
+			term.Dice = append(term.Dice, die)
+		}
+
+		event.Terms = append(event.Terms, term)
+	}
+
+	if fairness := message.Fairness; fairness != nil {
+		event.Fairness = &Fairness{
+			ServerSeedID:   fairness.ServerSeedId,
+			ServerSeedHash: fairness.ServerSeedHash,
+			ClientSeed:     fairness.ClientSeed,
+			Nonce:          fairness.Nonce,
+		}
+	}
+
+	return event
+}
@@ -0,0 +1,108 @@
+package events
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/demo/rolldice/pkg/messaging/serde"
+)
+
+func TestRollEventRoundTrip(t *testing.T) {
+	event := &RollEvent{
+		RollID:     "01J0ABCDEF",
+		Expression: "4d6kh3!r1+2",
+		Terms: []RollTerm{
+			{Notation: "4d6kh3!r1", Sign: 1, Sides: 6, Subtotal: 15, Dice: []Die{
+				{Value: 6, Kept: true, Exploded: true},
+				{Value: 3, Kept: true},
+				{Value: 6, Kept: true, Rerolled: []int{1}},
+				{Value: 2, Kept: false},
+			}},
+			{Notation: "2", Sign: 1, Subtotal: 2},
+		},
+		Result:    17,
+		RollerID:  "ada",
+		SessionID: "table-1",
+		Fairness: &Fairness{
+			ServerSeedID:   "seed-1",
+			ServerSeedHash: "c0ffee",
+			ClientSeed:     "lucky",
+			Nonce:          7,
+		},
+		Timestamp: "2026-10-17T12:00:00Z",
+	}
+
+	for _, format := range []serde.Format{serde.FormatJSONSchema, serde.FormatAvro, serde.FormatProtobuf} {
+		t.Run(string(format), func(t *testing.T) {
+			ctx := context.Background()
+
+			registry, err := serde.NewFileRegistry(filepath.Join(t.TempDir(), "schemas.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			codec, err := NewRollEventCodec(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			serializer, err := serde.NewSerializer(ctx, registry, RollEventSubject, codec)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			codecs, err := NewRollEventCodecs()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			deserializer, err := serde.NewDeserializer(ctx, registry, RollEventSubject, codecs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := serializer.Serialize(event)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if data[0] != serde.MagicByte {
+				t.Errorf("first byte = %d, want the magic byte", data[0])
+			}
+			if id := int(binary.BigEndian.Uint32(data[1:5])); id != serializer.Schema().ID {
+				t.Errorf("schema ID in the header = %d, want %d", id, serializer.Schema().ID)
+			}
+
+			decoded, err := DecodeRollEvent(ctx, deserializer, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(decoded, event) {
+				t.Errorf("round trip = %+v, want %+v", decoded, event)
+			}
+		})
+	}
+}
+
+func TestDecodeRollEventReadsPlainJSON(t *testing.T) {
+	event := &RollEvent{RollID: "01J0ABCDEF", Expression: "1d6", Terms: []RollTerm{{Notation: "1d6", Sign: 1, Sides: 6, Subtotal: 4}}, Result: 4}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeRollEvent(context.Background(), nil, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(decoded, event) {
+		t.Errorf("decoded = %+v, want %+v", decoded, event)
+	}
+}
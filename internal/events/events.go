@@ -0,0 +1,42 @@
+package events
+
+const (
+	// EventTypePrefix starts the CloudEvents type of every event, session events follow it with their own type
+	EventTypePrefix = "demo.rolldice."
+	RollEventType   = EventTypePrefix + "roll.rolled"
+)
+
+// RollEvent is published to RollTopic for every roll, its schemas are roll_event.schema.json, roll_event.avsc
+// and proto/rolldice/events/v1/roll_event.proto, a field changed here must be changed in all three
+type RollEvent struct {
+	RollID     string     `json:"roll_id" avro:"roll_id"`
+	Expression string     `json:"expression" avro:"expression"`
+	Terms      []RollTerm `json:"terms" avro:"terms"`
+	Result     int        `json:"result" avro:"result"`
+	RollerID   string     `json:"roller_id,omitempty" avro:"roller_id"`
+	SessionID  string     `json:"session_id,omitempty" avro:"session_id"`
+	Fairness   *Fairness  `json:"fairness,omitempty" avro:"fairness"`
+	Timestamp  string     `json:"timestamp" avro:"timestamp"`
+}
+
+type RollTerm struct {
+	Notation string `json:"notation" avro:"notation"`
+	Sign     int    `json:"sign" avro:"sign"`
+	Sides    int    `json:"sides,omitempty" avro:"sides"`
+	Dice     []Die  `json:"dice,omitempty" avro:"dice"`
+	Subtotal int    `json:"subtotal" avro:"subtotal"`
+}
+
+type Die struct {
+	Value    int   `json:"value" avro:"value"`
+	Kept     bool  `json:"kept" avro:"kept"`
+	Exploded bool  `json:"exploded,omitempty" avro:"exploded"`
+	Rerolled []int `json:"rerolled,omitempty" avro:"rerolled"`
+}
+
+type Fairness struct {
+	ServerSeedID   string `json:"server_seed_id" avro:"server_seed_id"`
+	ServerSeedHash string `json:"server_seed_hash" avro:"server_seed_hash"`
+	ClientSeed     string `json:"client_seed" avro:"client_seed"`
+	Nonce          int64  `json:"nonce" avro:"nonce"`
+}
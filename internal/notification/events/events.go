@@ -1,7 +1,24 @@
 package events
 
-type RollEvent struct {
-	RollID    string `json:"roll_id"`
-	Result    int    `json:"result"`
-	Timestamp string `json:"timestamp"`
+type PlayerScore struct {
+	PlayerID string `json:"player_id"`
+	Name     string `json:"name"`
+	Score    int    `json:"score"`
+}
+
+type SessionEvent struct {
+	Type       string        `json:"type"`
+	SessionID  string        `json:"session_id"`
+	Name       string        `json:"name"`
+	Expression string        `json:"expression"`
+	Status     string        `json:"status"`
+	Round      int           `json:"round"`
+	PlayerID   string        `json:"player_id,omitempty"`
+	PlayerName string        `json:"player_name,omitempty"`
+	RollID     string        `json:"roll_id,omitempty"`
+	Result     *int          `json:"result,omitempty"`
+	NextPlayer *PlayerScore  `json:"next_player,omitempty"`
+	Scores     []PlayerScore `json:"scores"`
+	Winners    []PlayerScore `json:"winners,omitempty"`
+	Timestamp  string        `json:"timestamp"`
 }
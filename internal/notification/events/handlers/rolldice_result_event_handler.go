@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/demo/rolldice/internal/notification/events"
+	"github.com/demo/rolldice/internal/events"
 	"github.com/demo/rolldice/internal/notification/models"
 	"github.com/demo/rolldice/internal/notification/services"
 	"github.com/sirupsen/logrus"
@@ -31,7 +31,7 @@ func (h *RollDiceResultNotiEventHandler) Handle(ctx context.Context, event *even
 		Messages: []models.Message{
 			{
 				Type: "text",
-				Text: fmt.Sprintf("Rolled result: %d", event.Result),
+				Text: rolledText(event),
 			},
 		},
 	}
@@ -42,3 +42,15 @@ func (h *RollDiceResultNotiEventHandler) Handle(ctx context.Context, event *even
 
 	return nil
 }
+
+func rolledText(event *events.RollEvent) string {
+	if event.Expression == "" {
+		return fmt.Sprintf("Rolled result: %d", event.Result)
+	}
+
+	if event.RollerID != "" {
+		return fmt.Sprintf("%s rolled %s: %d", event.RollerID, event.Expression, event.Result)
+	}
+
+	return fmt.Sprintf("Rolled %s: %d", event.Expression, event.Result)
+}
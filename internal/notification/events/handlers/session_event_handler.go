@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/demo/rolldice/internal/notification/events"
+	"github.com/demo/rolldice/internal/notification/models"
+	"github.com/demo/rolldice/internal/notification/services"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type SessionNotiEventHandler struct {
+	logger      *logrus.Logger
+	tracer      trace.Tracer
+	lineService *services.LineService
+}
+
+func SessionEventHandler(lineService *services.LineService, logger *logrus.Logger, tracer trace.Tracer) *SessionNotiEventHandler {
+	return &SessionNotiEventHandler{logger, tracer, lineService}
+}
+
+func (h *SessionNotiEventHandler) Handle(ctx context.Context, event *events.SessionEvent) error {
+	ctx, span := h.tracer.Start(ctx, "Start processing SessionEvent")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("app.session.id", event.SessionID),
+		attribute.String("app.session.event", event.Type),
+	)
+
+	text := sessionText(event)
+	if text == "" {
+		h.logger.WithContext(ctx).Warnf("Skip unknown session event type: %s", event.Type)
+		return nil
+	}
+
+	payload := models.PushMessage{
+		To: os.Getenv("LINE_BOT_RECEIVER_ID"),
+		Messages: []models.Message{
+			{
+				Type: "text",
+				Text: text,
+			},
+		},
+	}
+
+	h.lineService.SendPushMessage(ctx, payload)
+
+	h.logger.WithContext(ctx).Infof("Send notification for %s of session %s", event.Type, event.SessionID)
+
+	return nil
+}
+
+func sessionText(event *events.SessionEvent) string {
+	var text string
+
+	switch event.Type {
+	case "session.created":
+		text = fmt.Sprintf("Session %q is open, roll %s", event.Name, event.Expression)
+	case "session.joined":
+		text = fmt.Sprintf("%s joined %q", event.PlayerName, event.Name)
+	case "session.rolled":
+		result := 0
+		if event.Result != nil {
+			result = *event.Result
+		}
+		text = fmt.Sprintf("%s rolled %d in %q (round %d)", event.PlayerName, result, event.Name, event.Round)
+	case "session.finished":
+		return fmt.Sprintf("Session %q finished, %s", event.Name, winnersText(event.Winners))
+	default:
+		return ""
+	}
+
+	if event.NextPlayer != nil {
+		text += fmt.Sprintf("\n%s, it's your turn", event.NextPlayer.Name)
+	}
+
+	return text
+}
+
+func winnersText(winners []events.PlayerScore) string {
+	if len(winners) == 0 {
+		return "nobody played"
+	}
+
+	names := make([]string, len(winners))
+	for i, winner := range winners {
+		names[i] = winner.Name
+	}
+
+	return fmt.Sprintf("%s won with %d", strings.Join(names, " and "), winners[0].Score)
+}
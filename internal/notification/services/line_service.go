@@ -14,6 +14,7 @@ import (
 
 const (
 	apiURL      = "https://api.line.me/v2/bot/message/push"
+	botInfoURL  = "https://api.line.me/v2/bot/info"
 	contentType = "application/json"
 )
 
@@ -56,3 +57,23 @@ func (s *LineService) SendPushMessage(ctx context.Context, payload models.PushMe
 	log.Println("Message sent successfully!")
 	return nil
 }
+
+// VerifyToken checks the channel access token against the bot info endpoint
+func (s *LineService) VerifyToken(ctx context.Context) error {
+	headers := map[string]string{
+		"Authorization": "Bearer " + s.authToken,
+	}
+
+	res, err := s.HTTPClient.Get(ctx, botInfoURL, headers)
+
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("LINE token rejected. Status code: %d", res.StatusCode)
+	}
+
+	return nil
+}
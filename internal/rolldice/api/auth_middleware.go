@@ -0,0 +1,72 @@
+package api
+
+import (
+	"github.com/demo/rolldice/internal/rolldice/auth"
+	"github.com/demo/rolldice/pkg/health"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// publicPaths are served to anonymous callers, probes and API clients fetching the contract cannot authenticate
+var publicPaths = map[string]bool{
+	health.LivenessPath:  true,
+	health.ReadinessPath: true,
+	"/openapi.json":      true,
+}
+
+// Authentication rejects anonymous requests with a 401 problem and puts the caller in the request context and on the span,
+// every request passes through when the authenticator has no credentials configured
+func Authentication(authenticator *auth.Authenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !authenticator.Enabled() || publicPaths[c.Path()] {
+				return next(c)
+			}
+
+			request := c.Request()
+			ctx := request.Context()
+			span := trace.SpanFromContext(ctx)
+
+			identity, err := authenticator.Authenticate(request.Header.Get(APIKeyHeader), request.Header.Get(echo.HeaderAuthorization))
+
+			if err != nil {
+				span.SetAttributes(attribute.String("app.auth.error", err.Error()))
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="rolldice"`)
+				return err
+			}
+
+			span.SetAttributes(
+				semconv.EnduserID(identity.Subject),
+				attribute.String("app.auth.method", identity.Method),
+			)
+
+			c.SetRequest(request.WithContext(auth.WithIdentity(ctx, identity)))
+
+			return next(c)
+		}
+	}
+}
+
+// Admin only lets subjects listed in admins through, like Authentication it lets every request through when
+// the authenticator has no credentials configured
+func Admin(authenticator *auth.Authenticator, admins map[string]bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if authenticator.Enabled() && !admins[auth.Subject(c.Request().Context())] {
+				return auth.ErrNotAdmin
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// KeyBySubject rate limits authenticated callers by subject rather than by IP, behind Authentication
+func KeyBySubject(c echo.Context) (string, string, bool) {
+	subject := auth.Subject(c.Request().Context())
+	return subject, "subject", subject != ""
+}
@@ -0,0 +1,336 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/demo/rolldice/internal/events"
+	"github.com/demo/rolldice/internal/rolldice/auth"
+	"github.com/demo/rolldice/internal/rolldice/random"
+	"github.com/demo/rolldice/internal/rolldice/repositories"
+	"github.com/demo/rolldice/internal/rolldice/services"
+	"github.com/demo/rolldice/pkg/database"
+	"github.com/demo/rolldice/pkg/idgen"
+	"github.com/demo/rolldice/pkg/messaging/serde"
+	"github.com/demo/rolldice/pkg/middlewares"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+var (
+	echoParam = regexp.MustCompile(`:(\w+)`)
+	specParam = regexp.MustCompile(`\{(\w+)\}`)
+)
+
+// streamingOperations hold the connection open, the contract test only checks that they are registered
+var streamingOperations = map[string]bool{
+	"streamRolls": true,
+	"watchRolls":  true,
+}
+
+// newContractServer serves the API the way cmd/rolldice does, without authentication, rate limits or Kafka
+func newContractServer(t *testing.T) (*echo.Echo, *openapi3.T) {
+	t.Helper()
+
+	tracer := noop.NewTracerProvider().Tracer("")
+	meter := metricnoop.NewMeterProvider().Meter("")
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "rolldice.db"), tracer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	rollRepository, err := repositories.NewSQLiteRollRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seedRepository, err := repositories.NewSQLiteSeedRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outboxRepository, err := repositories.NewSQLiteOutboxRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idempotencyRepository, err := repositories.NewSQLiteIdempotencyRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessionRepository, err := repositories.NewSQLiteSessionRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := serde.NewFileRegistry(filepath.Join(t.TempDir(), "schemas.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codec, err := events.NewRollEventCodec(serde.FormatJSONSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serializer, err := serde.NewSerializer(context.Background(), registry, events.RollEventSubject, codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The relay is never run, outbox messages stay in SQLite
+	relay, err := services.NewOutboxRelay(tracer, logger, meter, outboxRepository, transactionalProducer{}, time.Minute, 100, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	streamService, err := services.NewStreamService(tracer, logger, meter, rollRepository)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statsService, err := services.NewStatsService(tracer, logger, meter, rollRepository, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator, err := auth.NewAuthenticator(nil, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idGenerator := idgen.NewULIDGenerator()
+	fairnessService := services.NewFairnessService(tracer, logger, seedRepository, idGenerator)
+	rolldiceService := services.NewRollDiceService(tracer, logger, random.NewSeededSource(1), rollRepository, idGenerator, fairnessService, streamService, relay, serializer)
+	idempotencyService := services.NewIdempotencyService(tracer, logger, idempotencyRepository, rollRepository, time.Hour)
+	sessionService := services.NewSessionService(tracer, logger, relay, sessionRepository, idGenerator, rolldiceService)
+
+	doc, err := LoadOpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validator, err := middlewares.OpenAPIValidator(doc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = middlewares.HTTPErrorHandler
+	e.Use(validator)
+
+	router := NewRouter(e, APIVersion{Name: "v1"}, APIVersion{Name: "v2"})
+
+	InitOpenAPIHandler(e)
+	InitRolldiceHandler(router, rolldiceService, idempotencyService)
+	InitFairnessHandler(router, fairnessService, rolldiceService, Admin(authenticator, nil))
+	InitStreamHandler(router, streamService, time.Minute, nil)
+	InitStatsHandler(router, statsService)
+	InitSessionHandler(router, sessionService)
+
+	return e, doc
+}
+
+// transactionalProducer accepts batches, it is never called
+type transactionalProducer struct {
+	services.OutboxProducer
+}
+
+func (transactionalProducer) Transactional() bool {
+	return true
+}
+
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	e, doc := newContractServer(t)
+
+	registered := map[string]bool{}
+
+	for _, route := range e.Routes() {
+		if route.Path == "/openapi.json" {
+			continue
+		}
+
+		registered[route.Method+" "+route.Path] = true
+
+		path := echoParam.ReplaceAllString(versionPrefix.ReplaceAllString(route.Path, "/"), "{$1}")
+		if item := doc.Paths.Find(path); item == nil || item.GetOperation(route.Method) == nil {
+			t.Errorf("%s %s is served but missing from openapi.json", route.Method, route.Path)
+		}
+	}
+
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			for _, server := range doc.Servers {
+				route := strings.TrimSuffix(server.URL, "/") + specParam.ReplaceAllString(path, ":$1")
+				if !registered[method+" "+route] {
+					t.Errorf("%s %s is in openapi.json but not served", method, route)
+				}
+			}
+		}
+	}
+}
+
+func TestResponsesMatchOpenAPI(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		// save stores the string at this dotted path of the response under the name of the next field
+		save, as string
+	}{
+		{http.MethodGet, "/roll?{expr}=4d6kh3", "", http.StatusOK, "", ""},
+		{http.MethodGet, "/roll?{expr}=2d6&client_seed=abc&nonce=7", "", http.StatusOK, "id", "{fair}"},
+		{http.MethodGet, "/roll?{expr}=2d6&client_seed=abc&nonce=7", "", http.StatusConflict, "", ""},
+		{http.MethodGet, "/roll?{expr}=2x6", "", http.StatusBadRequest, "", ""},
+		{http.MethodPost, "/roll", `{"expression": "3d6", "roller": "ada"}`, http.StatusOK, "id", "{roll}"},
+		{http.MethodPost, "/roll", `{"expr": "3d6"}`, http.StatusBadRequest, "", ""},
+		{http.MethodGet, "/rolls?roller=ada&limit=1", "", http.StatusOK, "", ""},
+		{http.MethodPost, "/rolls/batch", `{"count": 2, "expression": "1d20"}`, http.StatusOK, "", ""},
+		{http.MethodGet, "/rolls/{roll}", "", http.StatusOK, "", ""},
+		{http.MethodGet, "/rolls/missing", "", http.StatusNotFound, "", ""},
+		{http.MethodGet, "/rolls/{roll}/verify", "", http.StatusUnprocessableEntity, "", ""},
+		{http.MethodGet, "/rolls/{fair}/verify", "", http.StatusOK, "", ""},
+		{http.MethodGet, "/seeds/current", "", http.StatusOK, "", ""},
+		{http.MethodPost, "/seeds/rotate", "", http.StatusOK, "revealed.id", "{seed}"},
+		{http.MethodGet, "/seeds/{seed}", "", http.StatusOK, "", ""},
+		{http.MethodGet, "/stats", "", http.StatusOK, "", ""},
+		{http.MethodPost, "/sessions", `{"name": "Friday", "expression": "2d6"}`, http.StatusCreated, "id", "{session}"},
+		{http.MethodGet, "/sessions/{session}", "", http.StatusOK, "", ""},
+		{http.MethodPost, "/sessions/{session}/players", `{"name": "Ada"}`, http.StatusCreated, "player.id", "{player}"},
+		{http.MethodPost, "/sessions/{session}/rolls", `{"player_id": "{player}"}`, http.StatusOK, "", ""},
+		{http.MethodPost, "/sessions/{session}/finish", "", http.StatusOK, "", ""},
+		{http.MethodPost, "/sessions/{session}/finish", "", http.StatusConflict, "", ""},
+	}
+
+	servers := []struct {
+		url  string
+		expr string
+	}{
+		{"/v1", "expr"},
+		{"/v2", "expression"},
+		{"", "expr"},
+	}
+
+	for _, server := range servers {
+		t.Run("server "+server.url, func(t *testing.T) {
+			e, doc := newContractServer(t)
+
+			router, err := gorillamux.NewRouter(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			saved := []string{"{expr}", server.expr}
+			covered := map[string]bool{}
+
+			for _, test := range tests {
+				replacer := strings.NewReplacer(saved...)
+				path := server.url + replacer.Replace(test.path)
+
+				request := httptest.NewRequest(test.method, path, strings.NewReader(replacer.Replace(test.body)))
+				if test.body != "" {
+					request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+				}
+				recorder := httptest.NewRecorder()
+
+				e.ServeHTTP(recorder, request)
+
+				if recorder.Code != test.status {
+					t.Errorf("%s %s = %d, want %d: %s", test.method, path, recorder.Code, test.status, recorder.Body)
+					continue
+				}
+
+				route, pathParams, err := router.FindRoute(request)
+				if err != nil {
+					t.Errorf("%s %s: %v", test.method, path, err)
+					continue
+				}
+				covered[route.Operation.OperationID] = true
+
+				err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+					RequestValidationInput: &openapi3filter.RequestValidationInput{
+						Request:    request,
+						PathParams: pathParams,
+						Route:      route,
+					},
+					Status: recorder.Code,
+					Header: recorder.Header(),
+					Body:   io.NopCloser(bytes.NewReader(recorder.Body.Bytes())),
+					Options: &openapi3filter.Options{
+						IncludeResponseStatus: true,
+					},
+				})
+				if err != nil {
+					t.Errorf("%s %s response does not match openapi.json: %v", test.method, path, err)
+				}
+
+				if test.save != "" {
+					saved = append(saved, test.as, lookup(t, recorder.Body.Bytes(), test.save))
+				}
+			}
+
+			for _, item := range doc.Paths.Map() {
+				for _, operation := range item.Operations() {
+					if !covered[operation.OperationID] && !streamingOperations[operation.OperationID] {
+						t.Errorf("%s is not exercised by the contract test", operation.OperationID)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPostRollReadsTheDocumentedExpression(t *testing.T) {
+	e, _ := newContractServer(t)
+
+	request := httptest.NewRequest(http.MethodPost, "/roll", strings.NewReader(`{"expression": "2d20kh1"}`))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	recorder := httptest.NewRecorder()
+
+	e.ServeHTTP(recorder, request)
+
+	if got := lookup(t, recorder.Body.Bytes(), "expression"); got != "2d20kh1" {
+		t.Errorf("rolled %q, want the expression of the body", got)
+	}
+}
+
+func lookup(t *testing.T, body []byte, path string) string {
+	t.Helper()
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		object, _ := value.(map[string]any)
+		value = object[key]
+	}
+
+	result, ok := value.(string)
+	if !ok {
+		t.Fatalf("%s is not a string in %s", path, body)
+	}
+
+	return result
+}
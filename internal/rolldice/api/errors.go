@@ -0,0 +1,12 @@
+package api
+
+import (
+	exception "github.com/demo/rolldice/pkg/exceptions"
+)
+
+var errInvalidBody = exception.ErrInvalidRequest.WithMessage("invalid request body")
+
+// invalidRequest is a 400 problem whose detail tells the client what to fix
+func invalidRequest(message string) error {
+	return exception.ErrInvalidRequest.WithMessage(message)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/demo/rolldice/internal/rolldice/services"
+	"github.com/labstack/echo/v4"
+)
+
+type FairnessHandler struct {
+	fairnessService *services.FairnessService
+	rolldiceService *services.RollDiceService
+}
+
+// admin guards seed rotation, which reveals the active seed to everyone
+func InitFairnessHandler(r Router, fairnessService *services.FairnessService, rolldiceService *services.RollDiceService, admin echo.MiddlewareFunc) {
+	handler := &FairnessHandler{
+		fairnessService,
+		rolldiceService,
+	}
+
+	r.GET("/seeds/current", handler.CurrentSeed)
+	r.POST("/seeds/rotate", handler.RotateSeed, admin)
+	r.GET("/seeds/:id", handler.GetSeed)
+	r.GET("/rolls/:id/verify", handler.VerifyRoll)
+}
+
+func (h *FairnessHandler) CurrentSeed(c echo.Context) error {
+	seed, err := h.fairnessService.Commitment(c.Request().Context())
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, seed)
+}
+
+func (h *FairnessHandler) GetSeed(c echo.Context) error {
+	seed, err := h.fairnessService.Seed(c.Request().Context(), c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, seed)
+}
+
+func (h *FairnessHandler) RotateSeed(c echo.Context) error {
+	revealed, current, err := h.fairnessService.Rotate(c.Request().Context())
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, RotateSeedResponse{
+		Revealed: revealed,
+		Current:  current,
+	})
+}
+
+func (h *FairnessHandler) VerifyRoll(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	roll, err := h.rolldiceService.GetRoll(ctx, c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	verification, err := h.fairnessService.Verify(ctx, roll)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, verification)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"github.com/demo/rolldice/internal/rolldice/models"
+)
+
+// The request and response bodies below are described in openapi.json, contract_test.go fails when they drift apart
+
+type RollRequestBody struct {
+	Expression string `json:"expression"`
+	ClientSeed string `json:"client_seed"`
+	Nonce      *int64 `json:"nonce"`
+	Roller     string `json:"roller"`
+}
+
+type BatchRollRequest struct {
+	Count       int      `json:"count"`
+	Expression  string   `json:"expression"`
+	Expressions []string `json:"expressions"`
+	Roller      string   `json:"roller"`
+}
+
+type BatchRollResponse struct {
+	Rolls []*models.Roll `json:"rolls"`
+}
+
+type RotateSeedResponse struct {
+	Revealed *models.ServerSeed `json:"revealed"`
+	Current  *models.ServerSeed `json:"current"`
+}
+
+type CreateSessionRequest struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	MaxRounds  int    `json:"max_rounds"`
+}
+
+type JoinSessionRequest struct {
+	Name string `json:"name"`
+}
+
+type JoinSessionResponse struct {
+	Session *models.Session       `json:"session"`
+	Player  *models.SessionPlayer `json:"player"`
+}
+
+type SessionRollRequest struct {
+	PlayerID string `json:"player_id"`
+}
+
+type SessionRollResponse struct {
+	Session *models.Session `json:"session"`
+	Roll    *models.Roll    `json:"roll"`
+}
+
+type FinishSessionResponse struct {
+	Session *models.Session        `json:"session"`
+	Winners []models.SessionPlayer `json:"winners"`
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+)
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// LoadOpenAPI parses the contract of the rolldice API and checks that it is a valid OpenAPI 3 document
+func LoadOpenAPI() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+
+	doc, err := loader.LoadFromData(openAPIDocument)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load openapi.json: %w", err)
+	}
+
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid openapi.json: %w", err)
+	}
+
+	return doc, nil
+}
+
+func InitOpenAPIHandler(e *echo.Echo) {
+	e.GET("/openapi.json", func(c echo.Context) error {
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, openAPIDocument)
+	})
+}
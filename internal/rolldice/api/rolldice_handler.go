@@ -1,31 +1,256 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/demo/rolldice/internal/rolldice/models"
 	"github.com/demo/rolldice/internal/rolldice/services"
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	maxBatchSize    = 100
+
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
 )
 
 type RolldiceHandler struct {
-	rolldiceService *services.RollDiceService
+	rolldiceService    *services.RollDiceService
+	idempotencyService *services.IdempotencyService
 }
 
-func InitRolldiceHandler(e *echo.Echo, rolldiceService *services.RollDiceService) {
+func InitRolldiceHandler(r Router, rolldiceService *services.RollDiceService, idempotencyService *services.IdempotencyService) {
 	handler := &RolldiceHandler{
 		rolldiceService,
+		idempotencyService,
 	}
 
-	e.Group("/")
-	e.GET("/roll", handler.Roll)
+	r.GET("/roll", handler.Roll)
+	r.POST("/roll", handler.PostRoll)
+	r.GET("/rolls", handler.ListRolls)
+	r.POST("/rolls/batch", handler.RollBatch)
+	r.GET("/rolls/:id", handler.GetRoll)
 }
 
 func (h *RolldiceHandler) Roll(c echo.Context) error {
-	result, err := h.rolldiceService.Dice(c.Request().Context())
+	expression, err := expressionParam(c)
+
+	if err != nil {
+		return err
+	}
+
+	request := services.RollRequest{
+		Expression: expression,
+		RollerID:   c.QueryParam("roller"),
+		ClientSeed: c.QueryParam("client_seed"),
+	}
+
+	if nonce := c.QueryParam("nonce"); nonce != "" {
+		value, err := strconv.ParseInt(nonce, 10, 64)
+		if err != nil || value < 0 {
+			return invalidRequest("nonce must be a non-negative integer")
+		}
+		request.Nonce = &value
+	}
+
+	roll, err := h.rolldiceService.Dice(c.Request().Context(), request)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, roll)
+}
+
+// PostRoll rolls like Roll, a retry carrying the same Idempotency-Key gets the stored roll back
+func (h *RolldiceHandler) PostRoll(c echo.Context) error {
+	var body RollRequestBody
+
+	if err := c.Bind(&body); err != nil {
+		return errInvalidBody
+	}
+
+	if body.Nonce != nil && *body.Nonce < 0 {
+		return invalidRequest("nonce must be a non-negative integer")
+	}
+
+	request := services.RollRequest{
+		Expression: body.Expression,
+		RollerID:   body.Roller,
+		ClientSeed: body.ClientSeed,
+		Nonce:      body.Nonce,
+	}
+
+	ctx := c.Request().Context()
+	key := c.Request().Header.Get(IdempotencyKeyHeader)
+
+	if key == "" {
+		roll, err := h.rolldiceService.Dice(ctx, request)
+
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, roll)
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		return invalidRequest("Idempotency-Key must be at most 255 characters")
+	}
+
+	roll, replayed, err := h.idempotencyService.Do(ctx, key, request, h.rolldiceService.Dice)
+
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("app.idempotency.replayed", replayed))
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"message": err.Error()})
+		return err
+	}
+
+	if replayed {
+		c.Response().Header().Set(IdempotentReplayedHeader, "true")
+	}
+
+	return c.JSON(http.StatusOK, roll)
+}
+
+func (h *RolldiceHandler) RollBatch(c echo.Context) error {
+	var body BatchRollRequest
+
+	if err := c.Bind(&body); err != nil {
+		return errInvalidBody
+	}
+
+	requests, err := body.rollRequests()
+
+	if err != nil {
+		return err
+	}
+
+	rolls, err := h.rolldiceService.DiceBatch(c.Request().Context(), requests)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, BatchRollResponse{Rolls: rolls})
+}
+
+func (b BatchRollRequest) rollRequests() ([]services.RollRequest, error) {
+	if len(b.Expressions) > 0 && b.Count > 0 {
+		return nil, invalidRequest("use either count or expressions, not both")
+	}
+
+	expressions := b.Expressions
+	if len(expressions) == 0 {
+		if b.Count < 1 {
+			return nil, invalidRequest("count or expressions is required")
+		}
+		expressions = make([]string, b.Count)
+		for i := range expressions {
+			expressions[i] = b.Expression
+		}
+	}
+
+	if len(expressions) > maxBatchSize {
+		return nil, invalidRequest("a batch can hold at most 100 rolls")
+	}
+
+	requests := make([]services.RollRequest, len(expressions))
+	for i, expression := range expressions {
+		requests[i] = services.RollRequest{Expression: expression, RollerID: b.Roller}
+	}
+
+	return requests, nil
+}
+
+func (h *RolldiceHandler) GetRoll(c echo.Context) error {
+	roll, err := h.rolldiceService.GetRoll(c.Request().Context(), c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, roll)
+}
+
+func (h *RolldiceHandler) ListRolls(c echo.Context) error {
+	filter, err := parseRollFilter(c)
+
+	if err != nil {
+		return err
+	}
+
+	page, err := h.rolldiceService.ListRolls(c.Request().Context(), filter)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+func parseRollFilter(c echo.Context) (models.RollFilter, error) {
+	filter := models.RollFilter{
+		RollerID:  c.QueryParam("roller"),
+		SessionID: c.QueryParam("session"),
+		Cursor:    c.QueryParam("cursor"),
+		Limit:     defaultPageSize,
+	}
+
+	if from := c.QueryParam("from"); from != "" {
+		value, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return filter, invalidRequest("from must be an RFC 3339 timestamp")
+		}
+		filter.From = &value
+	}
+
+	if to := c.QueryParam("to"); to != "" {
+		value, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return filter, invalidRequest("to must be an RFC 3339 timestamp")
+		}
+		filter.To = &value
+	}
+
+	if result := c.QueryParam("result"); result != "" {
+		value, err := strconv.Atoi(result)
+		if err != nil {
+			return filter, invalidRequest("result must be an integer")
+		}
+		filter.Result = &value
+	}
+
+	if limit := c.QueryParam("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > maxPageSize {
+			return filter, invalidRequest("limit must be between 1 and 100")
+		}
+		filter.Limit = value
+	}
+
+	return filter, nil
+}
+
+// expressionParam reads expr in v1, v2 names it expression like the request bodies do
+func expressionParam(c echo.Context) (string, error) {
+	name, renamed := "expression", "expr"
+	if Version(c) == "v1" {
+		name, renamed = renamed, name
+	}
+
+	if c.QueryParam(renamed) != "" {
+		return "", invalidRequest(fmt.Sprintf("%s is named %s in %s", renamed, name, Version(c)))
 	}
 
-	return c.JSON(http.StatusOK, map[string]int{"result": result})
+	return c.QueryParam(name), nil
 }
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/demo/rolldice/internal/rolldice/services"
+	"github.com/labstack/echo/v4"
+)
+
+const maxSessionNameLength = 100
+
+type SessionHandler struct {
+	sessionService *services.SessionService
+}
+
+func InitSessionHandler(r Router, sessionService *services.SessionService) {
+	handler := &SessionHandler{
+		sessionService,
+	}
+
+	r.POST("/sessions", handler.CreateSession)
+	r.GET("/sessions/:id", handler.GetSession)
+	r.POST("/sessions/:id/players", handler.JoinSession)
+	r.POST("/sessions/:id/rolls", handler.RollSession)
+	r.POST("/sessions/:id/finish", handler.FinishSession)
+}
+
+func (h *SessionHandler) CreateSession(c echo.Context) error {
+	var body CreateSessionRequest
+
+	if err := c.Bind(&body); err != nil {
+		return errInvalidBody
+	}
+
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" || len(body.Name) > maxSessionNameLength {
+		return invalidRequest("name must be between 1 and 100 characters")
+	}
+	if body.MaxRounds < 0 || body.MaxRounds > services.MaxSessionRounds {
+		return invalidRequest("max_rounds must be between 0 and 100")
+	}
+
+	session, err := h.sessionService.Create(c.Request().Context(), services.SessionRequest{
+		Name:       body.Name,
+		Expression: body.Expression,
+		MaxRounds:  body.MaxRounds,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, session)
+}
+
+func (h *SessionHandler) GetSession(c echo.Context) error {
+	session, err := h.sessionService.Get(c.Request().Context(), c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, session)
+}
+
+func (h *SessionHandler) JoinSession(c echo.Context) error {
+	var body JoinSessionRequest
+
+	if err := c.Bind(&body); err != nil {
+		return errInvalidBody
+	}
+
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" || len(body.Name) > maxSessionNameLength {
+		return invalidRequest("name must be between 1 and 100 characters")
+	}
+
+	session, player, err := h.sessionService.Join(c.Request().Context(), c.Param("id"), body.Name)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, JoinSessionResponse{
+		Session: session,
+		Player:  player,
+	})
+}
+
+func (h *SessionHandler) RollSession(c echo.Context) error {
+	var body SessionRollRequest
+
+	if err := c.Bind(&body); err != nil {
+		return errInvalidBody
+	}
+
+	if body.PlayerID == "" {
+		return invalidRequest("player_id is required")
+	}
+
+	session, roll, err := h.sessionService.Roll(c.Request().Context(), c.Param("id"), body.PlayerID)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, SessionRollResponse{
+		Session: session,
+		Roll:    roll,
+	})
+}
+
+func (h *SessionHandler) FinishSession(c echo.Context) error {
+	session, err := h.sessionService.Finish(c.Request().Context(), c.Param("id"))
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, FinishSessionResponse{
+		Session: session,
+		Winners: session.Leaders(),
+	})
+}
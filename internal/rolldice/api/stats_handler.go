@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/dice"
+	"github.com/demo/rolldice/internal/rolldice/services"
+	"github.com/labstack/echo/v4"
+)
+
+const defaultStatsWindow = 24 * time.Hour
+
+type StatsHandler struct {
+	statsService *services.StatsService
+}
+
+func InitStatsHandler(r Router, statsService *services.StatsService) {
+	handler := &StatsHandler{
+		statsService,
+	}
+
+	r.GET("/stats", handler.Stats)
+}
+
+func (h *StatsHandler) Stats(c echo.Context) error {
+	request, err := parseStatsRequest(c)
+
+	if err != nil {
+		return err
+	}
+
+	result, err := h.statsService.Stats(c.Request().Context(), request)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// parseStatsRequest reads sides and a window given either as from/to or as a duration ending now
+func parseStatsRequest(c echo.Context) (services.StatsRequest, error) {
+	request := services.StatsRequest{
+		Sides: services.DefaultStatsSides,
+		To:    time.Now().UTC(),
+	}
+
+	if sides := c.QueryParam("sides"); sides != "" {
+		value, err := strconv.Atoi(sides)
+		if err != nil || value < 2 || value > dice.MaxSides {
+			return request, invalidRequest("sides must be between 2 and 1000")
+		}
+		request.Sides = value
+	}
+
+	window := defaultStatsWindow
+	if value := c.QueryParam("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return request, invalidRequest("window must be a positive duration such as 1h or 30m")
+		}
+		window = parsed
+	}
+
+	if to := c.QueryParam("to"); to != "" {
+		value, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return request, invalidRequest("to must be an RFC 3339 timestamp")
+		}
+		request.To = value
+	}
+
+	request.From = request.To.Add(-window)
+	if from := c.QueryParam("from"); from != "" {
+		value, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return request, invalidRequest("from must be an RFC 3339 timestamp")
+		}
+		request.From = value
+	}
+
+	return request, nil
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/repositories"
+	"github.com/demo/rolldice/internal/rolldice/services"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	LastEventIDHeader = "Last-Event-ID"
+	sseRetry          = 3 * time.Second
+	wsWriteTimeout    = 10 * time.Second
+)
+
+type StreamHandler struct {
+	streamService *services.StreamService
+	heartbeat     time.Duration
+	upgrader      websocket.Upgrader
+}
+
+func InitStreamHandler(r Router, streamService *services.StreamService, heartbeat time.Duration, allowedOrigins map[string]bool) {
+	handler := &StreamHandler{
+		streamService,
+		heartbeat,
+		websocket.Upgrader{CheckOrigin: checkOrigin(allowedOrigins)},
+	}
+
+	r.GET("/rolls/stream", handler.StreamRolls)
+	r.GET("/rolls/ws", handler.WatchRolls)
+}
+
+// checkOrigin accepts same-host and allowed origins, so other sites cannot open a WebSocket with the user's credentials.
+// Requests without an Origin header come from clients other than browsers
+func checkOrigin(allowedOrigins map[string]bool) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+
+		value, err := url.Parse(origin)
+		if err != nil || value.Host == "" {
+			return false
+		}
+
+		return strings.EqualFold(value.Host, r.Host) || allowedOrigins[strings.ToLower(value.Scheme+"://"+value.Host)]
+	}
+}
+
+// StreamRolls pushes rolls as Server-Sent Events, a reconnecting client resumes after its Last-Event-ID
+func (h *StreamHandler) StreamRolls(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	lastEventID := c.Request().Header.Get(LastEventIDHeader)
+	if lastEventID == "" {
+		lastEventID = c.QueryParam("last_event_id")
+	}
+
+	stream, err := h.streamService.Subscribe(ctx, "sse", streamFilter(c), lastEventID)
+
+	if errors.Is(err, repositories.ErrRollNotFound) {
+		return invalidRequest("unknown last event ID")
+	}
+	if err != nil {
+		return err
+	}
+
+	response := c.Response()
+
+	// The stream outlives SERVER_READ_TIMEOUT and SERVER_WRITE_TIMEOUT, heartbeats detect dead clients instead.
+	// An expired read deadline would cancel the request context, not only fail reads
+	controller := http.NewResponseController(response)
+	for _, clear := range []func(time.Time) error{controller.SetReadDeadline, controller.SetWriteDeadline} {
+		if err := clear(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			stream.Close(err)
+			return err
+		}
+	}
+
+	response.Header().Set(echo.HeaderContentType, "text/event-stream")
+	response.Header().Set(echo.HeaderCacheControl, "no-cache")
+	response.Header().Set(echo.HeaderConnection, "keep-alive")
+	response.Header().Set("X-Accel-Buffering", "no")
+	response.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(response, "retry: %d\n\n", sseRetry.Milliseconds())
+	response.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case roll, ok := <-stream.C:
+			if !ok {
+				stream.Close(nil)
+				return nil
+			}
+
+			data, _ := json.Marshal(roll)
+			if _, err := fmt.Fprintf(response, "id: %s\nevent: roll\ndata: %s\n\n", roll.ID, data); err != nil {
+				stream.Close(err)
+				return nil
+			}
+			response.Flush()
+			stream.Sent()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(response, ": heartbeat\n\n"); err != nil {
+				stream.Close(err)
+				return nil
+			}
+			response.Flush()
+		case <-ctx.Done():
+			stream.Close(nil)
+			return nil
+		}
+	}
+}
+
+// WatchRolls pushes rolls as JSON text messages over a WebSocket, pings double as heartbeats
+func (h *StreamHandler) WatchRolls(c echo.Context) error {
+	conn, err := h.upgrader.Upgrade(c.Response(), c.Request(), nil)
+
+	if err != nil {
+		// Upgrade already replied with an error status
+		return nil
+	}
+
+	defer conn.Close()
+
+	ctx := c.Request().Context()
+
+	stream, err := h.streamService.Subscribe(ctx, "websocket", streamFilter(c), c.QueryParam("last_event_id"))
+
+	if err != nil {
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()), time.Now().Add(wsWriteTimeout))
+		return nil
+	}
+
+	// The read loop only handles pongs and the close handshake, clients do not send messages
+	closed := make(chan struct{})
+	conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * h.heartbeat))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case roll, ok := <-stream.C:
+			if !ok {
+				err := stream.Err()
+				stream.Close(nil)
+				if err != nil {
+					conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, err.Error()), time.Now().Add(wsWriteTimeout))
+				}
+				return nil
+			}
+
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := conn.WriteJSON(roll); err != nil {
+				stream.Close(err)
+				return nil
+			}
+			stream.Sent()
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				stream.Close(err)
+				return nil
+			}
+		case <-closed:
+			stream.Close(nil)
+			return nil
+		}
+	}
+}
+
+func streamFilter(c echo.Context) services.StreamFilter {
+	return services.StreamFilter{
+		SessionID: c.QueryParam("session"),
+		RollerID:  c.QueryParam("roller"),
+	}
+}
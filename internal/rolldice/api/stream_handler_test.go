@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	check := checkOrigin(map[string]bool{"https://dashboard.example.com": true})
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},
+		{"http://rolldice.local:8080", true},
+		{"HTTP://RollDice.local:8080", true},
+		{"https://dashboard.example.com", true},
+		{"https://Dashboard.example.com", true},
+		{"http://dashboard.example.com", false},
+		{"https://dashboard.example.com:8443", false},
+		{"https://evil.example.com", false},
+		{"http://rolldice.local:9090", false},
+		{"null", false},
+		{"://", false},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, "http://rolldice.local:8080/rolls/ws", nil)
+		if test.origin != "" {
+			request.Header.Set("Origin", test.origin)
+		}
+
+		if got := check(request); got != test.want {
+			t.Errorf("origin %q allowed = %v, want %v", test.origin, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DeprecationHeader = "Deprecation"
+	SunsetHeader      = "Sunset"
+
+	versionKey = "api_version"
+)
+
+var versionPrefix = regexp.MustCompile(`^/v[0-9]+/`)
+
+// APIVersion is mounted under /Name, a deprecated version announces its Deprecation and Sunset dates on every response
+type APIVersion struct {
+	Name        string
+	Deprecation time.Time
+	Sunset      time.Time
+}
+
+type versionGroup struct {
+	group      *echo.Group
+	middleware echo.MiddlewareFunc
+}
+
+// Router mounts every route on each API version, handlers that change shape between versions branch on Version
+type Router []versionGroup
+
+// NewRouter mounts a group per version, the first version is also served without a prefix as before versioning
+func NewRouter(e *echo.Echo, versions ...APIVersion) Router {
+	router := Router{}
+
+	for _, version := range versions {
+		router = append(router, versionGroup{e.Group("/" + version.Name), versionMiddleware(version)})
+	}
+
+	if len(versions) > 0 {
+		router = append(router, versionGroup{e.Group(""), versionMiddleware(versions[0])})
+	}
+
+	return router
+}
+
+// The middleware goes on each route rather than on the group, a group middleware would claim unknown paths as well
+func (r Router) GET(path string, handler echo.HandlerFunc, middleware ...echo.MiddlewareFunc) {
+	for _, version := range r {
+		version.group.GET(path, handler, append([]echo.MiddlewareFunc{version.middleware}, middleware...)...)
+	}
+}
+
+func (r Router) POST(path string, handler echo.HandlerFunc, middleware ...echo.MiddlewareFunc) {
+	for _, version := range r {
+		version.group.POST(path, handler, append([]echo.MiddlewareFunc{version.middleware}, middleware...)...)
+	}
+}
+
+// Version is the API version of the route that matched the request
+func Version(c echo.Context) string {
+	version, _ := c.Get(versionKey).(string)
+	return version
+}
+
+// UnversionedRoute is the route without its version prefix, so per-route rate limits are shared by every version
+func UnversionedRoute(c echo.Context) string {
+	return versionPrefix.ReplaceAllString(c.Path(), "/")
+}
+
+func versionMiddleware(version APIVersion) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(versionKey, version.Name)
+
+			trace.SpanFromContext(c.Request().Context()).SetAttributes(attribute.String("app.api.version", version.Name))
+
+			header := c.Response().Header()
+			if !version.Deprecation.IsZero() {
+				// RFC 9745 structured field date
+				header.Set(DeprecationHeader, fmt.Sprintf("@%d", version.Deprecation.Unix()))
+			}
+			if !version.Sunset.IsZero() {
+				header.Set(SunsetHeader, version.Sunset.UTC().Format(http.TimeFormat))
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRollExpressionParameterByVersion(t *testing.T) {
+	e, _ := newContractServer(t)
+
+	tests := []struct {
+		path       string
+		status     int
+		expression string
+	}{
+		{"/roll?expr=2d4", http.StatusOK, "2d4"},
+		{"/v1/roll?expr=2d4", http.StatusOK, "2d4"},
+		{"/v1/roll?expression=2d4", http.StatusBadRequest, ""},
+		{"/v2/roll?expression=2d4", http.StatusOK, "2d4"},
+		{"/v2/roll?expr=2d4", http.StatusBadRequest, ""},
+		{"/v2/roll", http.StatusOK, "1d6"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+
+			e.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			if recorder.Code != test.status {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, test.status, recorder.Body)
+			}
+			if test.expression != "" {
+				if got := lookup(t, recorder.Body.Bytes(), "expression"); got != test.expression {
+					t.Errorf("expression = %q, want %q", got, test.expression)
+				}
+			}
+		})
+	}
+}
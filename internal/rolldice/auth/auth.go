@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	exception "github.com/demo/rolldice/pkg/exceptions"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+var (
+	ErrMissingCredentials = exception.New("auth.missing_credentials", exception.CategoryUnauthenticated, "missing API key or bearer token")
+	ErrInvalidAPIKey      = exception.New("auth.invalid_api_key", exception.CategoryUnauthenticated, "invalid API key")
+	ErrInvalidToken       = exception.New("auth.invalid_token", exception.CategoryUnauthenticated, "invalid bearer token")
+	ErrNotAdmin           = exception.New("auth.not_admin", exception.CategoryForbidden, "only admins can do this")
+)
+
+// Identity is the authenticated caller, Subject is the API key owner or the sub claim of the token
+type Identity struct {
+	Subject string
+	Method  string
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// Subject is empty when ctx carries no authenticated caller
+func Subject(ctx context.Context) string {
+	identity, _ := ctx.Value(identityKey{}).(Identity)
+	return identity.Subject
+}
+
+// Authenticator verifies static API keys and JWT bearer tokens signed by a key of a local JWKS
+type Authenticator struct {
+	apiKeys map[[sha256.Size]byte]string
+	keys    JWKS
+	parser  *jwt.Parser
+}
+
+// NewAuthenticator takes API keys as subject to key, jwksPath may be empty to only accept API keys
+func NewAuthenticator(apiKeys map[string]string, jwksPath, issuer, audience string) (*Authenticator, error) {
+	authenticator := &Authenticator{
+		apiKeys: make(map[[sha256.Size]byte]string, len(apiKeys)),
+	}
+
+	// Keys are looked up by hash so the comparison does not leak how much of a key matched
+	for subject, key := range apiKeys {
+		authenticator.apiKeys[sha256.Sum256([]byte(key))] = subject
+	}
+
+	if jwksPath != "" {
+		keys, err := LoadJWKS(jwksPath)
+		if err != nil {
+			return nil, err
+		}
+		authenticator.keys = keys
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+	}
+	if issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+	authenticator.parser = jwt.NewParser(options...)
+
+	return authenticator, nil
+}
+
+// Enabled is false when neither API keys nor a JWKS are configured
+func (a *Authenticator) Enabled() bool {
+	return len(a.apiKeys) > 0 || len(a.keys) > 0
+}
+
+// Authenticate checks the API key if one is given, the Authorization header value otherwise
+func (a *Authenticator) Authenticate(apiKey, authorization string) (Identity, error) {
+	if apiKey != "" {
+		subject, ok := a.apiKeys[sha256.Sum256([]byte(apiKey))]
+		if !ok {
+			return Identity{}, ErrInvalidAPIKey
+		}
+		return Identity{Subject: subject, Method: MethodAPIKey}, nil
+	}
+
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return Identity{}, ErrMissingCredentials
+	}
+
+	subject, err := a.verifyToken(token)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	return Identity{Subject: subject, Method: MethodJWT}, nil
+}
+
+func (a *Authenticator) verifyToken(token string) (string, error) {
+	if len(a.keys) == 0 {
+		return "", ErrInvalidToken.WithMessage("invalid bearer token: no JWKS configured")
+	}
+
+	claims := jwt.RegisteredClaims{}
+
+	_, err := a.parser.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return a.keys.Key(kid)
+	})
+	if err != nil {
+		return "", ErrInvalidToken.WithMessage(fmt.Sprintf("invalid bearer token: %v", err))
+	}
+
+	if claims.Subject == "" {
+		return "", ErrInvalidToken.WithMessage("invalid bearer token: missing sub claim")
+	}
+
+	return claims.Subject, nil
+}
@@ -0,0 +1,265 @@
+package dice
+
+import (
+	"context"
+	"sort"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Source is the randomness used to roll a single die, Intn returns a value in [0, n)
+type Source interface {
+	Intn(n int) int
+}
+
+type Die struct {
+	Value    int   `json:"value"`
+	Kept     bool  `json:"kept"`
+	Exploded bool  `json:"exploded,omitempty"`
+	Rerolled []int `json:"rerolled,omitempty"`
+}
+
+type TermResult struct {
+	Notation string `json:"notation"`
+	Sign     int    `json:"sign"`
+	Sides    int    `json:"sides,omitempty"`
+	Dice     []Die  `json:"dice,omitempty"`
+	Subtotal int    `json:"subtotal"`
+}
+
+type Result struct {
+	Expression string       `json:"expression"`
+	Terms      []TermResult `json:"terms"`
+	Total      int          `json:"total"`
+}
+
+// Values returns the value of every die rolled, including dropped ones
+func (r *Result) Values() []int {
+	values := []int{}
+	for _, term := range r.Terms {
+		for _, die := range term.Dice {
+			values = append(values, die.Value)
+		}
+	}
+	return values
+}
+
+type Evaluator struct {
+	tracer trace.Tracer
+	source Source
+}
+
+func NewEvaluator(tracer trace.Tracer, source Source) *Evaluator {
+	return &Evaluator{
+		tracer,
+		source,
+	}
+}
+
+// Evaluate rolls every term of the expression, each step is recorded as a child span of ctx
+func (e *Evaluator) Evaluate(ctx context.Context, expr *Expression) (*Result, error) {
+	result := &Result{
+		Expression: expr.String(),
+	}
+
+	for _, term := range expr.Terms {
+		termResult := e.evaluateTerm(ctx, term)
+		result.Terms = append(result.Terms, termResult)
+	}
+
+	_, span := e.tracer.Start(ctx, "dice.total")
+	defer span.End()
+
+	for _, term := range result.Terms {
+		result.Total += term.Sign * term.Subtotal
+	}
+
+	span.SetAttributes(
+		attribute.String("app.dice.expression", result.Expression),
+		attribute.Int("app.dice.total", result.Total),
+	)
+
+	return result, nil
+}
+
+func (e *Evaluator) evaluateTerm(ctx context.Context, term Term) TermResult {
+	if term.Dice == nil {
+		return TermResult{
+			Notation: term.String(),
+			Sign:     term.Sign,
+			Subtotal: term.Constant,
+		}
+	}
+
+	ctx, span := e.tracer.Start(ctx, "dice.roll "+term.Dice.String())
+	defer span.End()
+
+	dice := e.rollDice(ctx, term.Dice)
+
+	for _, modifier := range orderedModifiers(term.Dice.Modifiers) {
+		switch modifier.Kind {
+		case Reroll, RerollOnce:
+			e.reroll(ctx, term.Dice, modifier, dice)
+		case Explode:
+			dice = e.explode(ctx, term.Dice, modifier, dice)
+		default:
+			e.selectDice(ctx, modifier, dice)
+		}
+	}
+
+	subtotal := 0
+	for _, die := range dice {
+		if die.Kept {
+			subtotal += die.Value
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("app.dice.count", term.Dice.Count),
+		attribute.Int("app.dice.sides", term.Dice.Sides),
+		attribute.IntSlice("app.dice.values", dieValues(dice)),
+		attribute.Int("app.dice.subtotal", subtotal),
+	)
+
+	return TermResult{
+		Notation: term.Dice.String(),
+		Sign:     term.Sign,
+		Sides:    term.Dice.Sides,
+		Dice:     dice,
+		Subtotal: subtotal,
+	}
+}
+
+func (e *Evaluator) rollDice(ctx context.Context, term *DiceTerm) []Die {
+	_, span := e.tracer.Start(ctx, "dice.throw")
+	defer span.End()
+
+	dice := make([]Die, term.Count)
+	for i := range dice {
+		dice[i] = Die{Value: e.rollDie(term.Sides), Kept: true}
+	}
+
+	span.SetAttributes(attribute.IntSlice("app.dice.values", dieValues(dice)))
+
+	return dice
+}
+
+func (e *Evaluator) reroll(ctx context.Context, term *DiceTerm, modifier Modifier, dice []Die) {
+	_, span := e.tracer.Start(ctx, "dice.modifier "+modifier.String())
+	defer span.End()
+
+	rerolls := 0
+	for i := range dice {
+		for modifier.Condition.Matches(dice[i].Value) && rerolls < MaxRerolls {
+			dice[i].Rerolled = append(dice[i].Rerolled, dice[i].Value)
+			dice[i].Value = e.rollDie(term.Sides)
+			rerolls++
+
+			if modifier.Kind == RerollOnce {
+				break
+			}
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("app.dice.rerolls", rerolls),
+		attribute.IntSlice("app.dice.values", dieValues(dice)),
+	)
+}
+
+func (e *Evaluator) explode(ctx context.Context, term *DiceTerm, modifier Modifier, dice []Die) []Die {
+	_, span := e.tracer.Start(ctx, "dice.modifier "+modifier.String())
+	defer span.End()
+
+	explosions := 0
+	for i := 0; i < len(dice) && explosions < MaxExplosions; i++ {
+		if modifier.Condition.Matches(dice[i].Value) {
+			dice[i].Exploded = true
+			dice = append(dice, Die{Value: e.rollDie(term.Sides), Kept: true})
+			explosions++
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("app.dice.explosions", explosions),
+		attribute.IntSlice("app.dice.values", dieValues(dice)),
+	)
+
+	return dice
+}
+
+func (e *Evaluator) selectDice(ctx context.Context, modifier Modifier, dice []Die) {
+	_, span := e.tracer.Start(ctx, "dice.modifier "+modifier.String())
+	defer span.End()
+
+	order := make([]int, len(dice))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return dice[order[a]].Value > dice[order[b]].Value
+	})
+
+	count := modifier.Count
+	if count > len(dice) {
+		count = len(dice)
+	}
+
+	// order is highest first, so each modifier maps to a contiguous range of it
+	var dropped []int
+	switch modifier.Kind {
+	case KeepHighest:
+		dropped = order[count:]
+	case KeepLowest:
+		dropped = order[:len(order)-count]
+	case DropHighest:
+		dropped = order[:count]
+	case DropLowest:
+		dropped = order[len(order)-count:]
+	}
+
+	droppedValues := []int{}
+	for _, i := range dropped {
+		dice[i].Kept = false
+		droppedValues = append(droppedValues, dice[i].Value)
+	}
+
+	span.SetAttributes(attribute.IntSlice("app.dice.dropped", droppedValues))
+}
+
+func (e *Evaluator) rollDie(sides int) int {
+	return e.source.Intn(sides) + 1
+}
+
+// orderedModifiers applies rerolls first, then explosions, then keep/drop, regardless of notation order
+func orderedModifiers(modifiers []Modifier) []Modifier {
+	rank := map[ModifierKind]int{
+		Reroll:     0,
+		RerollOnce: 0,
+		Explode:    1,
+	}
+
+	ordered := append([]Modifier{}, modifiers...)
+	sort.SliceStable(ordered, func(a, b int) bool {
+		rankA, ok := rank[ordered[a].Kind]
+		if !ok {
+			rankA = 2
+		}
+		rankB, ok := rank[ordered[b].Kind]
+		if !ok {
+			rankB = 2
+		}
+		return rankA < rankB
+	})
+
+	return ordered
+}
+
+func dieValues(dice []Die) []int {
+	values := make([]int, len(dice))
+	for i, die := range dice {
+		values[i] = die.Value
+	}
+	return values
+}
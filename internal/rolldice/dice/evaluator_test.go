@@ -0,0 +1,104 @@
+package dice
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/demo/rolldice/internal/rolldice/random"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		source string
+		faces  []int
+		total  int
+		values []int
+		kept   []bool
+	}{
+		{"3d6+2", []int{1, 2, 3}, 8, []int{1, 2, 3}, []bool{true, true, true}},
+		{"1d4+3-2", []int{4}, 5, []int{4}, []bool{true}},
+		{"d%", []int{100}, 100, []int{100}, []bool{true}},
+		{"4d6kh3", []int{1, 5, 3, 6}, 14, []int{1, 5, 3, 6}, []bool{false, true, true, true}},
+		{"4d6kl1", []int{1, 5, 3, 6}, 1, []int{1, 5, 3, 6}, []bool{true, false, false, false}},
+		{"4d6dh1", []int{1, 5, 3, 6}, 9, []int{1, 5, 3, 6}, []bool{true, true, true, false}},
+		{"4d6dl1", []int{1, 5, 3, 6}, 14, []int{1, 5, 3, 6}, []bool{false, true, true, true}},
+		{"2d20kh1", []int{7, 7}, 7, []int{7, 7}, []bool{true, false}},
+		{"2d6r1", []int{1, 1, 4, 2}, 6, []int{4, 2}, []bool{true, true}},
+		{"2d6ro1", []int{1, 1, 1, 1}, 2, []int{1, 1}, []bool{true, true}},
+		{"1d6!", []int{6, 6, 2}, 14, []int{6, 6, 2}, []bool{true, true, true}},
+		{"2d6!>4", []int{5, 1, 3}, 9, []int{5, 1, 3}, []bool{true, true, true}},
+		// Rerolls happen before explosions, explosions before keep and drop
+		{"2d6kh1!r1", []int{1, 6, 2, 3}, 6, []int{2, 6, 3}, []bool{false, true, false}},
+		{"-1d6+10", []int{4}, 6, []int{4}, []bool{true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			result := evaluate(t, test.source, test.faces...)
+
+			if result.Total != test.total {
+				t.Errorf("total = %d, want %d", result.Total, test.total)
+			}
+			if values := result.Values(); !reflect.DeepEqual(values, test.values) {
+				t.Errorf("values = %v, want %v", values, test.values)
+			}
+			kept := []bool{}
+			for _, term := range result.Terms {
+				for _, die := range term.Dice {
+					kept = append(kept, die.Kept)
+				}
+			}
+			if !reflect.DeepEqual(kept, test.kept) {
+				t.Errorf("kept = %v, want %v", kept, test.kept)
+			}
+		})
+	}
+}
+
+func TestEvaluateCaps(t *testing.T) {
+	t.Run("explosions", func(t *testing.T) {
+		result := evaluate(t, "1d2!>1", 2)
+
+		if dice := len(result.Terms[0].Dice); dice != 1+MaxExplosions {
+			t.Errorf("rolled %d dice, want %d", dice, 1+MaxExplosions)
+		}
+		if result.Total != 2*(1+MaxExplosions) {
+			t.Errorf("total = %d, want %d", result.Total, 2*(1+MaxExplosions))
+		}
+	})
+
+	t.Run("rerolls", func(t *testing.T) {
+		result := evaluate(t, "2d6r<6", 1)
+
+		die := result.Terms[0].Dice[0]
+		if len(die.Rerolled) != MaxRerolls {
+			t.Errorf("rerolled %d times, want %d", len(die.Rerolled), MaxRerolls)
+		}
+		if rerolled := len(result.Terms[0].Dice[1].Rerolled); rerolled != 0 {
+			t.Errorf("second die rerolled %d times once the cap was reached, want 0", rerolled)
+		}
+	})
+}
+
+func evaluate(t *testing.T, source string, faces ...int) *Result {
+	t.Helper()
+
+	expr, err := Parse(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scripted, err := random.NewScriptedSource(faces...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewEvaluator(noop.NewTracerProvider().Tracer(""), scripted).Evaluate(context.Background(), expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
@@ -0,0 +1,409 @@
+package dice
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	MaxDice       = 100
+	MaxSides      = 1000
+	MaxExplosions = 100
+	MaxRerolls    = 100
+	MaxConstant   = 1000000
+	MaxTerms      = 20
+)
+
+type ModifierKind string
+
+const (
+	KeepHighest ModifierKind = "kh"
+	KeepLowest  ModifierKind = "kl"
+	DropHighest ModifierKind = "dh"
+	DropLowest  ModifierKind = "dl"
+	Explode     ModifierKind = "!"
+	Reroll      ModifierKind = "r"
+	RerollOnce  ModifierKind = "ro"
+)
+
+type CompareOp string
+
+const (
+	Equal       CompareOp = "="
+	LessThan    CompareOp = "<"
+	GreaterThan CompareOp = ">"
+)
+
+type Condition struct {
+	Op    CompareOp
+	Value int
+}
+
+func (c Condition) Matches(value int) bool {
+	switch c.Op {
+	case LessThan:
+		return value < c.Value
+	case GreaterThan:
+		return value > c.Value
+	default:
+		return value == c.Value
+	}
+}
+
+func (c Condition) String() string {
+	if c.Op == Equal {
+		return strconv.Itoa(c.Value)
+	}
+	return string(c.Op) + strconv.Itoa(c.Value)
+}
+
+type Modifier struct {
+	Kind      ModifierKind
+	Count     int
+	Condition Condition
+}
+
+func (m Modifier) String() string {
+	switch m.Kind {
+	case KeepHighest, KeepLowest, DropHighest, DropLowest:
+		return string(m.Kind) + strconv.Itoa(m.Count)
+	default:
+		return string(m.Kind) + m.Condition.String()
+	}
+}
+
+type DiceTerm struct {
+	Count      int
+	Sides      int
+	Percentile bool
+	Modifiers  []Modifier
+}
+
+func (d DiceTerm) String() string {
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(d.Count))
+	b.WriteString("d")
+	if d.Percentile {
+		b.WriteString("%")
+	} else {
+		b.WriteString(strconv.Itoa(d.Sides))
+	}
+	for _, m := range d.Modifiers {
+		if m.Kind == Explode && m.Condition == (Condition{Op: Equal, Value: d.Sides}) {
+			b.WriteString(string(Explode))
+			continue
+		}
+		b.WriteString(m.String())
+	}
+	return b.String()
+}
+
+type Term struct {
+	Sign     int
+	Dice     *DiceTerm
+	Constant int
+}
+
+func (t Term) String() string {
+	if t.Dice != nil {
+		return t.Dice.String()
+	}
+	return strconv.Itoa(t.Constant)
+}
+
+type Expression struct {
+	Source string
+	Terms  []Term
+}
+
+// String returns the normalized notation, e.g. "d6+2" becomes "1d6+2"
+func (e *Expression) String() string {
+	var b strings.Builder
+	for i, t := range e.Terms {
+		if t.Sign < 0 {
+			b.WriteString("-")
+		} else if i > 0 {
+			b.WriteString("+")
+		}
+		b.WriteString(t.String())
+	}
+	return b.String()
+}
+
+type SyntaxError struct {
+	Expression string
+	Position   int
+	Message    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid dice expression %q at position %d: %s", e.Expression, e.Position, e.Message)
+}
+
+type parser struct {
+	source string
+	input  string
+	pos    int
+}
+
+// Parse parses standard dice notation such as "3d6+2", "4d6kh3", "2d20kl1", "d6!", "d%" or "4d6r1"
+func Parse(source string) (*Expression, error) {
+	p := &parser{
+		source: source,
+		input:  strings.ToLower(strings.Join(strings.Fields(source), "")),
+	}
+
+	if p.input == "" {
+		return nil, p.fail("expression is empty")
+	}
+
+	expr := &Expression{Source: source}
+
+	sign := 1
+	if p.peek() == '+' || p.peek() == '-' {
+		if p.next() == '-' {
+			sign = -1
+		}
+	}
+
+	for {
+		term, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		term.Sign = sign
+		expr.Terms = append(expr.Terms, term)
+
+		if p.done() {
+			break
+		}
+
+		switch p.next() {
+		case '+':
+			sign = 1
+		case '-':
+			sign = -1
+		default:
+			p.pos--
+			return nil, p.fail(fmt.Sprintf("unexpected character %q", p.peek()))
+		}
+
+		if len(expr.Terms) == MaxTerms {
+			return nil, p.fail(fmt.Sprintf("at most %d terms are allowed", MaxTerms))
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *parser) parseTerm() (Term, error) {
+	count, hasCount, err := p.parseInt()
+	if err != nil {
+		return Term{}, err
+	}
+
+	if p.peek() != 'd' {
+		if !hasCount {
+			return Term{}, p.fail("expected a number or dice")
+		}
+		if count > MaxConstant {
+			return Term{}, p.fail(fmt.Sprintf("constant must be at most %d", MaxConstant))
+		}
+		return Term{Constant: count}, nil
+	}
+	p.next()
+
+	if !hasCount {
+		count = 1
+	}
+	if count < 1 || count > MaxDice {
+		return Term{}, p.fail(fmt.Sprintf("dice count must be between 1 and %d", MaxDice))
+	}
+
+	dice := &DiceTerm{Count: count}
+
+	if p.peek() == '%' {
+		p.next()
+		dice.Sides = 100
+		dice.Percentile = true
+	} else {
+		sides, ok, err := p.parseInt()
+		if err != nil {
+			return Term{}, err
+		}
+		if !ok {
+			return Term{}, p.fail("expected number of sides")
+		}
+		if sides < 1 || sides > MaxSides {
+			return Term{}, p.fail(fmt.Sprintf("number of sides must be between 1 and %d", MaxSides))
+		}
+		dice.Sides = sides
+	}
+
+	for !p.done() && p.peek() != '+' && p.peek() != '-' {
+		modifier, err := p.parseModifier(dice)
+		if err != nil {
+			return Term{}, err
+		}
+		dice.Modifiers = append(dice.Modifiers, modifier)
+	}
+
+	if err := p.validate(dice); err != nil {
+		return Term{}, err
+	}
+
+	return Term{Dice: dice}, nil
+}
+
+func (p *parser) parseModifier(dice *DiceTerm) (Modifier, error) {
+	switch p.next() {
+	case 'k':
+		kind := KeepHighest
+		if p.peek() == 'h' || p.peek() == 'l' {
+			if p.next() == 'l' {
+				kind = KeepLowest
+			}
+		}
+		return p.parseCountModifier(kind, dice)
+	case 'd':
+		if p.peek() != 'h' && p.peek() != 'l' {
+			return Modifier{}, p.fail("expected 'dh' or 'dl'")
+		}
+		kind := DropHighest
+		if p.next() == 'l' {
+			kind = DropLowest
+		}
+		return p.parseCountModifier(kind, dice)
+	case '!':
+		condition := Condition{Op: Equal, Value: dice.Sides}
+		if !p.done() && p.peek() != '+' && p.peek() != '-' && !isModifierStart(p.peek()) {
+			parsed, err := p.parseCondition()
+			if err != nil {
+				return Modifier{}, err
+			}
+			condition = parsed
+		}
+		return Modifier{Kind: Explode, Condition: condition}, nil
+	case 'r':
+		kind := Reroll
+		if p.peek() == 'o' {
+			p.next()
+			kind = RerollOnce
+		}
+		condition, err := p.parseCondition()
+		if err != nil {
+			return Modifier{}, err
+		}
+		return Modifier{Kind: kind, Condition: condition}, nil
+	default:
+		p.pos--
+		return Modifier{}, p.fail(fmt.Sprintf("unknown modifier %q", p.peek()))
+	}
+}
+
+func (p *parser) parseCountModifier(kind ModifierKind, dice *DiceTerm) (Modifier, error) {
+	count, ok, err := p.parseInt()
+	if err != nil {
+		return Modifier{}, err
+	}
+	if !ok {
+		count = 1
+	}
+	if count < 1 || count > dice.Count {
+		return Modifier{}, p.fail(fmt.Sprintf("%s count must be between 1 and %d", kind, dice.Count))
+	}
+	return Modifier{Kind: kind, Count: count}, nil
+}
+
+func (p *parser) parseCondition() (Condition, error) {
+	op := Equal
+	switch p.peek() {
+	case '<':
+		p.next()
+		op = LessThan
+	case '>':
+		p.next()
+		op = GreaterThan
+	case '=':
+		p.next()
+	}
+
+	value, ok, err := p.parseInt()
+	if err != nil {
+		return Condition{}, err
+	}
+	if !ok {
+		return Condition{}, p.fail("expected a number")
+	}
+
+	return Condition{Op: op, Value: value}, nil
+}
+
+func (p *parser) validate(dice *DiceTerm) error {
+	selections := 0
+	for _, m := range dice.Modifiers {
+		switch m.Kind {
+		case KeepHighest, KeepLowest, DropHighest, DropLowest:
+			selections++
+		case Explode, Reroll:
+			if matchesEveryFace(m.Condition, dice.Sides) {
+				return p.fail(fmt.Sprintf("%s condition matches every face of d%d", m.Kind, dice.Sides))
+			}
+		}
+	}
+	if selections > 1 {
+		return p.fail("only one keep or drop modifier is allowed per dice term")
+	}
+	return nil
+}
+
+func (p *parser) parseInt() (int, bool, error) {
+	start := p.pos
+	for !p.done() && p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, false, nil
+	}
+
+	value, err := strconv.Atoi(p.input[start:p.pos])
+	if err != nil {
+		return 0, false, p.fail("number is out of range")
+	}
+	return value, true, nil
+}
+
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) next() byte {
+	c := p.peek()
+	p.pos++
+	return c
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) fail(message string) error {
+	return &SyntaxError{Expression: p.source, Position: p.pos, Message: message}
+}
+
+func isModifierStart(c byte) bool {
+	return c == 'k' || c == 'd' || c == 'r' || c == '!'
+}
+
+func matchesEveryFace(c Condition, sides int) bool {
+	for face := 1; face <= sides; face++ {
+		if !c.Matches(face) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package dice
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"3d6+2", "3d6+2"},
+		{"d6", "1d6"},
+		{" 2 D6 - 1 ", "2d6-1"},
+		{"-1d4+3", "-1d4+3"},
+		{"10", "10"},
+		{"4d6kh3", "4d6kh3"},
+		{"4d6k3", "4d6kh3"},
+		{"4d6k", "4d6kh1"},
+		{"2d20kl1", "2d20kl1"},
+		{"4d6dl1", "4d6dl1"},
+		{"4d6dh2", "4d6dh2"},
+		{"d6!", "1d6!"},
+		{"2d6!>4", "2d6!>4"},
+		{"d%", "1d%"},
+		{"4d6r1", "4d6r1"},
+		{"4d6r<3", "4d6r<3"},
+		{"4d6ro=1", "4d6ro1"},
+		{"4d6r1!kh3", "4d6r1!kh3"},
+		{"1000000-1000000", "1000000-1000000"},
+		{strings.Repeat("1+", 19) + "1", strings.Repeat("1+", 19) + "1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			expr, err := Parse(test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := expr.String(); got != test.want {
+				t.Errorf("Parse(%q) = %q, want %q", test.source, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSyntaxError(t *testing.T) {
+	tests := []struct {
+		source   string
+		position int
+		message  string
+	}{
+		{"", 0, "expression is empty"},
+		{"2d", 2, "expected number of sides"},
+		{"2d0", 3, "number of sides must be between 1 and 1000"},
+		{"2d1001", 6, "number of sides must be between 1 and 1000"},
+		{"0d6", 2, "dice count must be between 1 and 100"},
+		{"101d6", 4, "dice count must be between 1 and 100"},
+		{"2d6+", 4, "expected a number or dice"},
+		{"2d6*2", 3, `unknown modifier '*'`},
+		{"2 d6 )", 3, `unknown modifier ')'`},
+		{"2d6kh3", 6, "kh count must be between 1 and 2"},
+		{"2d6dx", 4, "expected 'dh' or 'dl'"},
+		{"4d6kh1dl1", 9, "only one keep or drop modifier is allowed per dice term"},
+		{"d6r<7", 5, "r condition matches every face of d6"},
+		{"d6!>0", 5, "! condition matches every face of d6"},
+		{"d6r", 3, "expected a number"},
+		{"99999999999999999999", 20, "number is out of range"},
+		{"1000001", 7, "constant must be at most 1000000"},
+		{"9223372036854775807+1", 19, "constant must be at most 1000000"},
+		{strings.Repeat("1+", 20) + "1", 40, "at most 20 terms are allowed"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			_, err := Parse(test.source)
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse(%q) error = %v, want a SyntaxError", test.source, err)
+			}
+			if syntaxErr.Position != test.position || syntaxErr.Message != test.message {
+				t.Errorf("Parse(%q) = %q at %d, want %q at %d", test.source, syntaxErr.Message, syntaxErr.Position, test.message, test.position)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotencyKey remembers which roll answered a request, an empty RollID means it is still in flight.
+// Keys are scoped to the caller who sent them, Subject is empty for anonymous callers
+type IdempotencyKey struct {
+	Subject     string
+	Key         string
+	RequestHash string
+	RollID      string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// OutboxMessage is an event stored in the same transaction as the change it announces, the relay publishes it later.
+// Type and ContentType are its CloudEvents attributes, TraceContext holds the propagation headers of the request that made the change.
+// Messages sharing a BatchID are published in one Kafka transaction, they must share a topic
+type OutboxMessage struct {
+	ID            string
+	BatchID       string
+	Topic         string
+	Key           string
+	Value         string
+	Type          string
+	ContentType   string
+	TraceContext  map[string]string
+	Attempts      int
+	LastError     string
+	CreatedAt     time.Time
+	NextAttemptAt time.Time
+	SentAt        *time.Time
+}
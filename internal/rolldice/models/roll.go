@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/dice"
+)
+
+type Roll struct {
+	ID         string            `json:"id"`
+	Expression string            `json:"expression"`
+	Terms      []dice.TermResult `json:"terms"`
+	Result     int               `json:"result"`
+	RollerID   string            `json:"roller_id,omitempty"`
+	SessionID  string            `json:"session_id,omitempty"`
+	Fairness   *Fairness         `json:"fairness,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+type RollFilter struct {
+	From      *time.Time
+	To        *time.Time
+	Result    *int
+	RollerID  string
+	SessionID string
+	Cursor    string
+	Limit     int
+}
+
+type RollPage struct {
+	Rolls      []Roll `json:"rolls"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
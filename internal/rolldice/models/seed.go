@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// ServerSeed is the secret behind provably fair rolls, only its hash is public until it is revealed
+type ServerSeed struct {
+	ID         string     `json:"id"`
+	Seed       string     `json:"seed,omitempty"`
+	Hash       string     `json:"hash"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevealedAt *time.Time `json:"revealed_at,omitempty"`
+}
+
+// Public hides the seed until it has been revealed
+func (s ServerSeed) Public() ServerSeed {
+	if s.RevealedAt == nil {
+		s.Seed = ""
+	}
+	return s
+}
+
+type Fairness struct {
+	ServerSeedID   string `json:"server_seed_id"`
+	ServerSeedHash string `json:"server_seed_hash"`
+	ClientSeed     string `json:"client_seed"`
+	Nonce          int64  `json:"nonce"`
+}
+
+type Verification struct {
+	RollID     string     `json:"roll_id"`
+	Fairness   Fairness   `json:"fairness"`
+	ServerSeed string     `json:"server_seed,omitempty"`
+	Revealed   bool       `json:"revealed"`
+	Verified   bool       `json:"verified"`
+	Expected   int        `json:"expected_result"`
+	Recomputed *int       `json:"recomputed_result,omitempty"`
+	RevealedAt *time.Time `json:"revealed_at,omitempty"`
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type SessionStatus string
+
+const (
+	SessionWaiting  SessionStatus = "waiting"
+	SessionPlaying  SessionStatus = "playing"
+	SessionFinished SessionStatus = "finished"
+)
+
+// SessionPlayer is bound to the authenticated caller who joined, Subject is empty for anonymous players
+type SessionPlayer struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Subject  string    `json:"-"`
+	Score    int       `json:"score"`
+	Rolls    int       `json:"rolls"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// Session is a room whose players roll Expression in join order, Turn counts the rolls made so far
+type Session struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Expression string          `json:"expression"`
+	Status     SessionStatus   `json:"status"`
+	MaxRounds  int             `json:"max_rounds,omitempty"`
+	Turn       int             `json:"turn"`
+	Players    []SessionPlayer `json:"players"`
+	Version    int             `json:"-"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// CurrentPlayer is the player whose turn it is, nil before anyone joined or once finished
+func (s *Session) CurrentPlayer() *SessionPlayer {
+	if len(s.Players) == 0 || s.Status == SessionFinished {
+		return nil
+	}
+	return &s.Players[s.Turn%len(s.Players)]
+}
+
+func (s *Session) Player(id string) *SessionPlayer {
+	for i := range s.Players {
+		if s.Players[i].ID == id {
+			return &s.Players[i]
+		}
+	}
+	return nil
+}
+
+// Round starts at 1 and increases once every player rolled
+func (s *Session) Round() int {
+	if len(s.Players) == 0 {
+		return 1
+	}
+	return s.Turn/len(s.Players) + 1
+}
+
+// Leaders are the players sharing the highest score
+func (s *Session) Leaders() []SessionPlayer {
+	leaders := []SessionPlayer{}
+	for _, player := range s.Players {
+		if len(leaders) == 0 || player.Score > leaders[0].Score {
+			leaders = []SessionPlayer{player}
+		} else if player.Score == leaders[0].Score {
+			leaders = append(leaders, player)
+		}
+	}
+	return leaders
+}
+
+func (s Session) MarshalJSON() ([]byte, error) {
+	type session Session
+
+	var currentPlayerID string
+	if player := s.CurrentPlayer(); player != nil {
+		currentPlayerID = player.ID
+	}
+
+	return json.Marshal(struct {
+		session
+		Round           int    `json:"round"`
+		CurrentPlayerID string `json:"current_player_id,omitempty"`
+	}{session(s), s.Round(), currentPlayerID})
+}
@@ -0,0 +1,39 @@
+package random
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"math"
+)
+
+type CryptoSource struct{}
+
+func NewCryptoSource() *CryptoSource {
+	return &CryptoSource{}
+}
+
+func (s *CryptoSource) Name() string {
+	return CryptoSourceName
+}
+
+func (s *CryptoSource) Intn(n int) int {
+	if n <= 0 {
+		panic("random: invalid argument to Intn")
+	}
+
+	// Reject values from the incomplete last bucket to avoid modulo bias
+	bound := uint64(n)
+	limit := math.MaxUint64 - math.MaxUint64%bound
+
+	var buf [8]byte
+	for {
+		if _, err := rand.Read(buf[:]); err != nil {
+			panic("random: crypto/rand failed: " + err.Error())
+		}
+
+		value := binary.BigEndian.Uint64(buf[:])
+		if value < limit {
+			return int(value % bound)
+		}
+	}
+}
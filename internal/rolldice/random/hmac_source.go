@@ -0,0 +1,69 @@
+package random
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"strconv"
+	"sync"
+)
+
+const HMACSourceName = "hmac-sha256"
+
+// HMACSource derives every die from HMAC-SHA256(serverSeed, "clientSeed:nonce:round"), so anyone
+// holding the revealed server seed can recompute the same sequence
+type HMACSource struct {
+	mu         sync.Mutex
+	serverSeed []byte
+	clientSeed string
+	nonce      int64
+	round      int
+	block      []byte
+}
+
+func NewHMACSource(serverSeed []byte, clientSeed string, nonce int64) *HMACSource {
+	return &HMACSource{
+		serverSeed: serverSeed,
+		clientSeed: clientSeed,
+		nonce:      nonce,
+	}
+}
+
+func (s *HMACSource) Name() string {
+	return HMACSourceName
+}
+
+func (s *HMACSource) Intn(n int) int {
+	if n <= 0 {
+		panic("random: invalid argument to Intn")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Reject values from the incomplete last bucket to avoid modulo bias
+	bound := uint64(n)
+	limit := (math.MaxUint32 + 1) - (math.MaxUint32+1)%bound
+
+	for {
+		value := uint64(s.nextUint32())
+		if value < limit {
+			return int(value % bound)
+		}
+	}
+}
+
+func (s *HMACSource) nextUint32() uint32 {
+	if len(s.block) < 4 {
+		mac := hmac.New(sha256.New, s.serverSeed)
+		mac.Write([]byte(s.clientSeed + ":" + strconv.FormatInt(s.nonce, 10) + ":" + strconv.Itoa(s.round)))
+		s.block = mac.Sum(nil)
+		s.round++
+	}
+
+	value := binary.BigEndian.Uint32(s.block[:4])
+	s.block = s.block[4:]
+
+	return value
+}
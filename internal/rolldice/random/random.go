@@ -0,0 +1,30 @@
+package random
+
+import (
+	"fmt"
+)
+
+const (
+	CryptoSourceName   = "crypto"
+	SeededSourceName   = "seeded"
+	ScriptedSourceName = "scripted"
+)
+
+// RandomSource provides the randomness behind every die, Intn returns a value in [0, n)
+type RandomSource interface {
+	Intn(n int) int
+	Name() string
+}
+
+func NewRandomSource(name string, seed int64, script []int) (RandomSource, error) {
+	switch name {
+	case "", CryptoSourceName:
+		return NewCryptoSource(), nil
+	case SeededSourceName:
+		return NewSeededSource(seed), nil
+	case ScriptedSourceName:
+		return NewScriptedSource(script...)
+	default:
+		return nil, fmt.Errorf("unknown random source %q", name)
+	}
+}
@@ -0,0 +1,90 @@
+package random
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScriptedSource(t *testing.T) {
+	tests := []struct {
+		name  string
+		faces []int
+		sides int
+		want  []int
+	}{
+		{"in order", []int{3, 1, 6}, 6, []int{2, 0, 5}},
+		{"starts over", []int{2, 5}, 6, []int{1, 4, 1, 4, 1}},
+		{"wraps faces above the sides", []int{7, 12, 4}, 6, []int{0, 5, 3}},
+		{"d%", []int{100, 1}, 100, []int{99, 0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source, err := NewScriptedSource(test.faces...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]int, len(test.want))
+			for i := range got {
+				got[i] = source.Intn(test.sides)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Intn(%d) = %v, want %v", test.sides, got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewScriptedSourceRejectsInvalidFaces(t *testing.T) {
+	for _, faces := range [][]int{nil, {1, 0}, {-3}} {
+		if _, err := NewScriptedSource(faces...); err == nil {
+			t.Errorf("NewScriptedSource(%v) succeeded, want an error", faces)
+		}
+	}
+}
+
+func TestSeededSourceReplays(t *testing.T) {
+	first, second := NewSeededSource(42), NewSeededSource(42)
+
+	for i := 0; i < 100; i++ {
+		if a, b := first.Intn(20), second.Intn(20); a != b {
+			t.Fatalf("roll %d = %d and %d with the same seed", i, a, b)
+		}
+	}
+}
+
+func TestNewRandomSource(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"", CryptoSourceName},
+		{CryptoSourceName, CryptoSourceName},
+		{SeededSourceName, SeededSourceName},
+		{ScriptedSourceName, ScriptedSourceName},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			source, err := NewRandomSource(test.name, 1, []int{4})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if source.Name() != test.want {
+				t.Errorf("Name() = %q, want %q", source.Name(), test.want)
+			}
+
+			for i := 0; i < 1000; i++ {
+				if value := source.Intn(6); value < 0 || value >= 6 {
+					t.Fatalf("Intn(6) = %d, want a value in [0, 6)", value)
+				}
+			}
+		})
+	}
+
+	if _, err := NewRandomSource("dice-tower", 0, nil); err == nil {
+		t.Error("unknown source name succeeded, want an error")
+	}
+}
@@ -0,0 +1,42 @@
+package random
+
+import (
+	"errors"
+	"sync"
+)
+
+// ScriptedSource returns a fixed list of die faces in order and starts over once exhausted
+type ScriptedSource struct {
+	mu     sync.Mutex
+	faces  []int
+	cursor int
+}
+
+func NewScriptedSource(faces ...int) (*ScriptedSource, error) {
+	if len(faces) == 0 {
+		return nil, errors.New("scripted random source needs at least one face")
+	}
+
+	for _, face := range faces {
+		if face < 1 {
+			return nil, errors.New("scripted random source faces must be positive")
+		}
+	}
+
+	return &ScriptedSource{faces: faces}, nil
+}
+
+func (s *ScriptedSource) Name() string {
+	return ScriptedSourceName
+}
+
+// Intn returns the next scripted face minus one, so a die with n sides shows that face when it is <= n
+func (s *ScriptedSource) Intn(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	face := s.faces[s.cursor]
+	s.cursor = (s.cursor + 1) % len(s.faces)
+
+	return (face - 1) % n
+}
@@ -0,0 +1,35 @@
+package random
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// SeededSource is deterministic, the same seed replays the same roll sequence
+type SeededSource struct {
+	mu   sync.Mutex
+	seed int64
+	rnd  *rand.Rand
+}
+
+func NewSeededSource(seed int64) *SeededSource {
+	return &SeededSource{
+		seed: seed,
+		rnd:  rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (s *SeededSource) Name() string {
+	return SeededSourceName
+}
+
+func (s *SeededSource) Seed() int64 {
+	return s.seed
+}
+
+func (s *SeededSource) Intn(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rnd.Intn(n)
+}
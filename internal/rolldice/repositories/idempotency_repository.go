@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/demo/rolldice/internal/rolldice/models"
+)
+
+type IdempotencyRepository interface {
+	// Reserve stores key unless an unexpired record exists, in which case that record is returned
+	Reserve(ctx context.Context, key *models.IdempotencyKey) (*models.IdempotencyKey, error)
+	Complete(ctx context.Context, subject, key, rollID string) error
+	Release(ctx context.Context, subject, key string) error
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/models"
+)
+
+// OutboxRepository is read by the relay, messages are written by the repository of the change they belong to,
+// e.g. RollRepository.Create
+type OutboxRepository interface {
+	// Pending returns up to limit unsent messages due at now, oldest first. A message waits for the older unsent
+	// messages of its topic and key, so they are published in order. A batch is returned whole, even over limit
+	Pending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxMessage, error)
+	// MarkSent marks all messages sent in one statement, so a batch is never partly sent
+	MarkSent(ctx context.Context, sentAt time.Time, ids ...string) error
+	// MarkFailed counts a failed attempt of every message, they are due again at nextAttemptAt
+	MarkFailed(ctx context.Context, cause string, nextAttemptAt time.Time, ids ...string) error
+	// DeleteSent removes messages sent before before and returns how many were removed
+	DeleteSent(ctx context.Context, before time.Time) (int64, error)
+	CountPending(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/models"
+	exception "github.com/demo/rolldice/pkg/exceptions"
+)
+
+var (
+	ErrRollNotFound  = exception.New("roll.not_found", exception.CategoryNotFound, "roll not found")
+	ErrInvalidCursor = exception.New("roll.invalid_cursor", exception.CategoryValidation, "invalid cursor")
+)
+
+type RollRepository interface {
+	// Create stores the roll and its outbox messages in one transaction, so the events exist if and only if the roll does
+	Create(ctx context.Context, roll *models.Roll, outbox ...*models.OutboxMessage) error
+	// CreateMany stores all rolls and their outbox messages in one transaction
+	CreateMany(ctx context.Context, rolls []*models.Roll, outbox ...*models.OutboxMessage) error
+	FindByID(ctx context.Context, id string) (*models.Roll, error)
+	List(ctx context.Context, filter models.RollFilter) (*models.RollPage, error)
+	// Each calls fn for every roll created in [from, to), oldest first
+	Each(ctx context.Context, from, to time.Time, fn func(*models.Roll) error) error
+}
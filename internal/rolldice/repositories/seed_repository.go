@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/demo/rolldice/internal/rolldice/models"
+	exception "github.com/demo/rolldice/pkg/exceptions"
+)
+
+var ErrSeedNotFound = exception.New("seed.not_found", exception.CategoryNotFound, "server seed not found")
+
+type SeedRepository interface {
+	Active(ctx context.Context) (*models.ServerSeed, error)
+	FindByID(ctx context.Context, id string) (*models.ServerSeed, error)
+	Create(ctx context.Context, seed *models.ServerSeed) error
+	// Rotate reveals the active seed and makes next the active one
+	Rotate(ctx context.Context, next *models.ServerSeed) (*models.ServerSeed, error)
+	NextNonce(ctx context.Context, id string) (int64, error)
+	// ClaimNonce records that clientSeed rolled nonce under seed id, it is false when the pair was already used
+	ClaimNonce(ctx context.Context, id, clientSeed string, nonce int64) (bool, error)
+}
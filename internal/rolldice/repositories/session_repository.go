@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/demo/rolldice/internal/rolldice/models"
+	exception "github.com/demo/rolldice/pkg/exceptions"
+)
+
+var (
+	ErrSessionNotFound = exception.New("session.not_found", exception.CategoryNotFound, "session not found")
+	ErrSessionConflict = exception.New("session.modified", exception.CategoryConflict, "session was modified concurrently").WithRetryable(true)
+)
+
+// SessionRepository writes the outbox messages of every change in the transaction of the change
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session, outbox ...*models.OutboxMessage) error
+	FindByID(ctx context.Context, id string) (*models.Session, error)
+	AddPlayer(ctx context.Context, session *models.Session, player *models.SessionPlayer, outbox ...*models.OutboxMessage) error
+	// Save stores the session state and player scores if nobody changed it since it was loaded
+	Save(ctx context.Context, session *models.Session, outbox ...*models.OutboxMessage) error
+	// SaveRoll is Save that also stores the roll, so a roll exists if and only if it is counted in the session
+	SaveRoll(ctx context.Context, session *models.Session, roll *models.Roll, outbox ...*models.OutboxMessage) error
+}
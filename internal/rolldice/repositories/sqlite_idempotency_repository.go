@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/models"
+	"github.com/demo/rolldice/pkg/database"
+)
+
+const idempotencyTable = "idempotency_keys"
+
+var idempotencyMigrations = []database.Migration{
+	{Name: "idempotency_keys_001_create", Statement: createIdempotencyTable},
+}
+
+const createIdempotencyTable = `CREATE TABLE IF NOT EXISTS idempotency_keys (
+	subject      TEXT NOT NULL DEFAULT '',
+	key          TEXT NOT NULL,
+	request_hash TEXT NOT NULL,
+	roll_id      TEXT NOT NULL DEFAULT '',
+	created_at   INTEGER NOT NULL,
+	expires_at   INTEGER NOT NULL,
+	PRIMARY KEY (subject, key)
+);
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);`
+
+const (
+	deleteExpiredKeys = `DELETE FROM idempotency_keys WHERE expires_at <= ?`
+	insertKey         = `INSERT INTO idempotency_keys (subject, key, request_hash, created_at, expires_at) VALUES (?, ?, ?, ?, ?) ON CONFLICT (subject, key) DO NOTHING`
+	selectKey         = `SELECT subject, key, request_hash, roll_id, created_at, expires_at FROM idempotency_keys WHERE subject = ? AND key = ?`
+	completeKey       = `UPDATE idempotency_keys SET roll_id = ? WHERE subject = ? AND key = ?`
+	deleteKey         = `DELETE FROM idempotency_keys WHERE subject = ? AND key = ? AND roll_id = ''`
+)
+
+type SQLiteIdempotencyRepository struct {
+	db *database.SQLite
+}
+
+func NewSQLiteIdempotencyRepository(db *database.SQLite) (*SQLiteIdempotencyRepository, error) {
+	if err := db.Migrate(context.Background(), idempotencyMigrations); err != nil {
+		return nil, fmt.Errorf("failed to migrate idempotency_keys table: %w", err)
+	}
+
+	return &SQLiteIdempotencyRepository{db}, nil
+}
+
+func (r *SQLiteIdempotencyRepository) Reserve(ctx context.Context, key *models.IdempotencyKey) (existing *models.IdempotencyKey, err error) {
+	ctx, span := r.db.StartSpan(ctx, "INSERT", idempotencyTable, insertKey)
+	defer func() { database.EndSpan(span, err) }()
+
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, deleteExpiredKeys, key.CreatedAt.UnixNano()); err != nil {
+		return nil, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, insertKey, key.Subject, key.Key, key.RequestHash, key.CreatedAt.UnixNano(), key.ExpiresAt.UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert idempotency key: %w", err)
+	}
+
+	if inserted, _ := result.RowsAffected(); inserted == 0 {
+		existing, err = scanIdempotencyKey(tx.QueryRowContext(ctx, selectKey, key.Subject, key.Key))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return existing, nil
+}
+
+func (r *SQLiteIdempotencyRepository) Complete(ctx context.Context, subject, key, rollID string) (err error) {
+	ctx, span := r.db.StartSpan(ctx, "UPDATE", idempotencyTable, completeKey)
+	defer func() { database.EndSpan(span, err) }()
+
+	if _, err = r.db.DB.ExecContext(ctx, completeKey, rollID, subject, key); err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteIdempotencyRepository) Release(ctx context.Context, subject, key string) (err error) {
+	ctx, span := r.db.StartSpan(ctx, "DELETE", idempotencyTable, deleteKey)
+	defer func() { database.EndSpan(span, err) }()
+
+	if _, err = r.db.DB.ExecContext(ctx, deleteKey, subject, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+func scanIdempotencyKey(row *sql.Row) (*models.IdempotencyKey, error) {
+	var (
+		key       models.IdempotencyKey
+		createdAt int64
+		expiresAt int64
+	)
+
+	err := row.Scan(&key.Subject, &key.Key, &key.RequestHash, &key.RollID, &createdAt, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("idempotency key expired while reserving it")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to select idempotency key: %w", err)
+	}
+
+	key.CreatedAt = time.Unix(0, createdAt).UTC()
+	key.ExpiresAt = time.Unix(0, expiresAt).UTC()
+
+	return &key, nil
+}
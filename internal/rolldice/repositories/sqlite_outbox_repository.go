@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/models"
+	"github.com/demo/rolldice/pkg/database"
+)
+
+const outboxTable = "outbox"
+
+var outboxMigrations = []database.Migration{
+	{Name: "outbox_001_create", Statement: createOutboxTable},
+	{Name: "outbox_002_event_attributes", Statement: addOutboxEventAttributes},
+}
+
+const createOutboxTable = `CREATE TABLE IF NOT EXISTS outbox (
+	id              TEXT PRIMARY KEY,
+	batch_id        TEXT NOT NULL DEFAULT '',
+	topic           TEXT NOT NULL,
+	key             TEXT NOT NULL,
+	value           TEXT NOT NULL,
+	trace_context   TEXT NOT NULL DEFAULT '{}',
+	attempts        INTEGER NOT NULL DEFAULT 0,
+	last_error      TEXT NOT NULL DEFAULT '',
+	created_at      INTEGER NOT NULL,
+	next_attempt_at INTEGER NOT NULL,
+	sent_at         INTEGER
+);
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (created_at, id) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_sent_at_idx ON outbox (sent_at) WHERE sent_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS outbox_key_idx ON outbox (topic, key, created_at, id) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_batch_idx ON outbox (batch_id) WHERE batch_id != '' AND sent_at IS NULL;`
+
+const addOutboxEventAttributes = `ALTER TABLE outbox ADD COLUMN type TEXT NOT NULL DEFAULT '';
+ALTER TABLE outbox ADD COLUMN content_type TEXT NOT NULL DEFAULT '';`
+
+const outboxColumns = `id, batch_id, topic, key, value, type, content_type, trace_context, attempts, last_error, created_at, next_attempt_at`
+
+const (
+	insertOutboxMessage = `INSERT INTO outbox (id, batch_id, topic, key, value, type, content_type, trace_context, created_at, next_attempt_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	selectPending       = `SELECT ` + outboxColumns + ` FROM outbox WHERE sent_at IS NULL AND next_attempt_at <= ? AND NOT EXISTS (` + selectOlderOfKey + `) ORDER BY created_at ASC, id ASC LIMIT ?`
+	selectOlderOfKey    = `SELECT 1 FROM outbox older WHERE older.sent_at IS NULL AND older.topic = outbox.topic AND older.key = outbox.key AND (older.created_at, older.id) < (outbox.created_at, outbox.id)`
+	selectBatch         = `SELECT ` + outboxColumns + ` FROM outbox WHERE batch_id = ? AND sent_at IS NULL ORDER BY created_at ASC, id ASC`
+	markSent            = `UPDATE outbox SET sent_at = ? WHERE id = ?`
+	markFailed          = `UPDATE outbox SET attempts = attempts + 1, last_error = ?, next_attempt_at = ? WHERE id = ?`
+	deleteSent          = `DELETE FROM outbox WHERE sent_at IS NOT NULL AND sent_at < ?`
+	countPending        = `SELECT COUNT(*) FROM outbox WHERE sent_at IS NULL`
+)
+
+type SQLiteOutboxRepository struct {
+	db *database.SQLite
+}
+
+func NewSQLiteOutboxRepository(db *database.SQLite) (*SQLiteOutboxRepository, error) {
+	if err := db.Migrate(context.Background(), outboxMigrations); err != nil {
+		return nil, fmt.Errorf("failed to migrate outbox table: %w", err)
+	}
+
+	return &SQLiteOutboxRepository{db}, nil
+}
+
+func (r *SQLiteOutboxRepository) Pending(ctx context.Context, now time.Time, limit int) (messages []*models.OutboxMessage, err error) {
+	ctx, span := r.db.StartSpan(ctx, "SELECT", outboxTable, selectPending)
+	defer func() { database.EndSpan(span, err) }()
+
+	page, err := r.query(ctx, selectPending, now.UnixNano(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select pending outbox messages: %w", err)
+	}
+
+	// The limit can cut a batch, every batch of the page is read again whole in its place
+	batches := map[string]bool{}
+
+	for _, message := range page {
+		if message.BatchID == "" {
+			messages = append(messages, message)
+			continue
+		}
+
+		if batches[message.BatchID] {
+			continue
+		}
+		batches[message.BatchID] = true
+
+		batch, err := r.query(ctx, selectBatch, message.BatchID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to select outbox batch %s: %w", message.BatchID, err)
+		}
+
+		messages = append(messages, batch...)
+	}
+
+	return messages, nil
+}
+
+func (r *SQLiteOutboxRepository) query(ctx context.Context, query string, args ...any) ([]*models.OutboxMessage, error) {
+	rows, err := r.db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []*models.OutboxMessage{}
+
+	for rows.Next() {
+		var (
+			message       models.OutboxMessage
+			traceContext  string
+			createdAt     int64
+			nextAttemptAt int64
+		)
+
+		if err := rows.Scan(&message.ID, &message.BatchID, &message.Topic, &message.Key, &message.Value, &message.Type, &message.ContentType, &traceContext, &message.Attempts, &message.LastError, &createdAt, &nextAttemptAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+
+		if err := json.Unmarshal([]byte(traceContext), &message.TraceContext); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal outbox trace context: %w", err)
+		}
+		message.CreatedAt = time.Unix(0, createdAt).UTC()
+		message.NextAttemptAt = time.Unix(0, nextAttemptAt).UTC()
+
+		messages = append(messages, &message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate outbox messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+func (r *SQLiteOutboxRepository) MarkSent(ctx context.Context, sentAt time.Time, ids ...string) (err error) {
+	ctx, span := r.db.StartSpan(ctx, "UPDATE", outboxTable, markSent)
+	defer func() { database.EndSpan(span, err) }()
+
+	if err = r.update(ctx, markSent, ids, sentAt.UnixNano()); err != nil {
+		return fmt.Errorf("failed to mark outbox message sent: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteOutboxRepository) MarkFailed(ctx context.Context, cause string, nextAttemptAt time.Time, ids ...string) (err error) {
+	ctx, span := r.db.StartSpan(ctx, "UPDATE", outboxTable, markFailed)
+	defer func() { database.EndSpan(span, err) }()
+
+	if err = r.update(ctx, markFailed, ids, cause, nextAttemptAt.UnixNano()); err != nil {
+		return fmt.Errorf("failed to mark outbox message failed: %w", err)
+	}
+
+	return nil
+}
+
+// update runs statement for every ID in one transaction, the ID is its last argument
+func (r *SQLiteOutboxRepository) update(ctx context.Context, statement string, ids []string, args ...any) error {
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, statement, append(args, id)...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *SQLiteOutboxRepository) DeleteSent(ctx context.Context, before time.Time) (deleted int64, err error) {
+	ctx, span := r.db.StartSpan(ctx, "DELETE", outboxTable, deleteSent)
+	defer func() { database.EndSpan(span, err) }()
+
+	result, err := r.db.DB.ExecContext(ctx, deleteSent, before.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sent outbox messages: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *SQLiteOutboxRepository) CountPending(ctx context.Context) (count int64, err error) {
+	ctx, span := r.db.StartSpan(ctx, "SELECT", outboxTable, countPending)
+	defer func() { database.EndSpan(span, err) }()
+
+	if err = r.db.DB.QueryRowContext(ctx, countPending).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count pending outbox messages: %w", err)
+	}
+
+	return count, nil
+}
+
+// insertOutboxMessages lets other repositories write messages in the transaction of their change
+func insertOutboxMessages(ctx context.Context, tx *sql.Tx, messages []*models.OutboxMessage) error {
+	for _, message := range messages {
+		traceContext, err := json.Marshal(message.TraceContext)
+		if err != nil {
+			return fmt.Errorf("failed to marshal outbox trace context: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, insertOutboxMessage, message.ID, message.BatchID, message.Topic, message.Key, message.Value, message.Type, message.ContentType, string(traceContext), message.CreatedAt.UnixNano(), message.NextAttemptAt.UnixNano()); err != nil {
+			return fmt.Errorf("failed to insert outbox message: %w", err)
+		}
+	}
+
+	return nil
+}
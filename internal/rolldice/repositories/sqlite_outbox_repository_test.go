@@ -0,0 +1,158 @@
+package repositories
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/models"
+	"github.com/demo/rolldice/pkg/database"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestPendingReturnsWholeBatches(t *testing.T) {
+	repository := newOutboxRepository(t)
+	ctx := context.Background()
+	start := time.Unix(1700000000, 0).UTC()
+
+	writeOutbox(t, repository,
+		&models.OutboxMessage{ID: "a", Key: "a", CreatedAt: start},
+		&models.OutboxMessage{ID: "b1", BatchID: "b", Key: "b1", CreatedAt: start.Add(time.Second)},
+		&models.OutboxMessage{ID: "b2", BatchID: "b", Key: "b2", CreatedAt: start.Add(2 * time.Second)},
+		&models.OutboxMessage{ID: "b3", BatchID: "b", Key: "b3", CreatedAt: start.Add(3 * time.Second)},
+		&models.OutboxMessage{ID: "c", Key: "c", CreatedAt: start.Add(4 * time.Second)},
+	)
+
+	now := start.Add(time.Minute)
+
+	tests := []struct {
+		limit int
+		want  []string
+	}{
+		{1, []string{"a"}},
+		{2, []string{"a", "b1", "b2", "b3"}},
+		{10, []string{"a", "b1", "b2", "b3", "c"}},
+	}
+
+	for _, test := range tests {
+		if ids := pendingIDs(t, repository, now, test.limit); !reflect.DeepEqual(ids, test.want) {
+			t.Errorf("Pending(%d) = %v, want %v", test.limit, ids, test.want)
+		}
+	}
+
+	if err := repository.MarkFailed(ctx, "broker down", now.Add(time.Minute), "b1", "b2", "b3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.MarkSent(ctx, now, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := pendingIDs(t, repository, now, 10); !reflect.DeepEqual(ids, []string{"c"}) {
+		t.Errorf("Pending after the batch failed = %v, want [c]", ids)
+	}
+
+	messages, err := repository.Pending(ctx, now.Add(time.Minute), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, message := range messages {
+		if message.Attempts != 1 || message.LastError != "broker down" {
+			t.Errorf("%s: %d attempts, last error %q, want 1 and %q", message.ID, message.Attempts, message.LastError, "broker down")
+		}
+	}
+	if len(messages) != 3 {
+		t.Errorf("Pending once the batch is due = %d messages, want the 3 of the batch", len(messages))
+	}
+}
+
+func TestPendingHoldsBackLaterMessagesOfAKey(t *testing.T) {
+	repository := newOutboxRepository(t)
+	ctx := context.Background()
+	start := time.Unix(1700000000, 0).UTC()
+
+	writeOutbox(t, repository,
+		&models.OutboxMessage{ID: "a", Key: "session", CreatedAt: start},
+		&models.OutboxMessage{ID: "b", Key: "session", CreatedAt: start.Add(time.Second)},
+		&models.OutboxMessage{ID: "c", Key: "other", CreatedAt: start.Add(2 * time.Second)},
+	)
+
+	now := start.Add(time.Minute)
+
+	if ids := pendingIDs(t, repository, now, 10); !reflect.DeepEqual(ids, []string{"a", "c"}) {
+		t.Errorf("Pending = %v, want [a c]", ids)
+	}
+
+	// b stays behind a while a waits for its retry
+	if err := repository.MarkFailed(ctx, "broker down", now.Add(time.Minute), "a"); err != nil {
+		t.Fatal(err)
+	}
+	if ids := pendingIDs(t, repository, now, 10); !reflect.DeepEqual(ids, []string{"c"}) {
+		t.Errorf("Pending after a failed = %v, want [c]", ids)
+	}
+
+	if err := repository.MarkSent(ctx, now, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if ids := pendingIDs(t, repository, now, 10); !reflect.DeepEqual(ids, []string{"b", "c"}) {
+		t.Errorf("Pending after a was sent = %v, want [b c]", ids)
+	}
+}
+
+func newOutboxRepository(t *testing.T) *SQLiteOutboxRepository {
+	t.Helper()
+
+	db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "rolldice.db"), noop.NewTracerProvider().Tracer(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	repository, err := NewSQLiteOutboxRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return repository
+}
+
+// writeOutbox stores messages due at their creation, on the topic of roll events
+func writeOutbox(t *testing.T, repository *SQLiteOutboxRepository, messages ...*models.OutboxMessage) {
+	t.Helper()
+
+	tx, err := repository.db.DB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	for _, message := range messages {
+		message.Topic = "poc.rolldice"
+		message.NextAttemptAt = message.CreatedAt
+	}
+
+	if err := insertOutboxMessages(context.Background(), tx, messages); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func pendingIDs(t *testing.T, repository *SQLiteOutboxRepository, now time.Time, limit int) []string {
+	t.Helper()
+
+	messages, err := repository.Pending(context.Background(), now, limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []string{}
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+
+	return ids
+}
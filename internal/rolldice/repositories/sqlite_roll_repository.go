@@ -0,0 +1,336 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/models"
+	"github.com/demo/rolldice/pkg/database"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const rollsTable = "rolls"
+
+var rollMigrations = []database.Migration{
+	{Name: "rolls_001_create", Statement: createRollsTable},
+	{Name: "rolls_002_fairness", Statement: addRollFairness},
+	{Name: "rolls_003_session", Statement: addRollSession},
+}
+
+const createRollsTable = `CREATE TABLE IF NOT EXISTS rolls (
+	id         TEXT PRIMARY KEY,
+	expression TEXT NOT NULL,
+	terms      TEXT NOT NULL,
+	result     INTEGER NOT NULL,
+	roller_id  TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS rolls_created_at_idx ON rolls (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS rolls_roller_id_idx ON rolls (roller_id, created_at DESC);`
+
+const addRollFairness = `ALTER TABLE rolls ADD COLUMN server_seed_id TEXT;
+ALTER TABLE rolls ADD COLUMN server_seed_hash TEXT;
+ALTER TABLE rolls ADD COLUMN client_seed TEXT;
+ALTER TABLE rolls ADD COLUMN nonce INTEGER;`
+
+const addRollSession = `ALTER TABLE rolls ADD COLUMN session_id TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS rolls_session_id_idx ON rolls (session_id, created_at DESC);`
+
+const (
+	insertRoll   = `INSERT INTO rolls (id, expression, terms, result, roller_id, created_at, server_seed_id, server_seed_hash, client_seed, nonce, session_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	selectRolls  = `SELECT id, expression, terms, result, roller_id, created_at, server_seed_id, server_seed_hash, client_seed, nonce, session_id FROM rolls`
+	selectRollBy = selectRolls + ` WHERE id = ?`
+	selectWindow = selectRolls + ` WHERE created_at >= ? AND created_at < ? ORDER BY created_at ASC, id ASC`
+)
+
+type SQLiteRollRepository struct {
+	db *database.SQLite
+}
+
+func NewSQLiteRollRepository(db *database.SQLite) (*SQLiteRollRepository, error) {
+	if err := db.Migrate(context.Background(), rollMigrations); err != nil {
+		return nil, fmt.Errorf("failed to migrate rolls table: %w", err)
+	}
+
+	// Create writes to the outbox, whether or not the relay's repository was created first
+	if err := db.Migrate(context.Background(), outboxMigrations); err != nil {
+		return nil, fmt.Errorf("failed to migrate outbox table: %w", err)
+	}
+
+	return &SQLiteRollRepository{db}, nil
+}
+
+func (r *SQLiteRollRepository) Create(ctx context.Context, roll *models.Roll, outbox ...*models.OutboxMessage) (err error) {
+	ctx, span := r.db.StartSpan(ctx, "INSERT", rollsTable, insertRoll)
+	defer func() { database.EndSpan(span, err) }()
+
+	span.SetAttributes(attribute.Int("app.outbox.message_count", len(outbox)))
+
+	args, err := insertArgs(roll)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, insertRoll, args...); err != nil {
+		return fmt.Errorf("failed to insert roll: %w", err)
+	}
+
+	if err = insertOutboxMessages(ctx, tx, outbox); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// CreateMany inserts all rolls in a single transaction
+func (r *SQLiteRollRepository) CreateMany(ctx context.Context, rolls []*models.Roll, outbox ...*models.OutboxMessage) (err error) {
+	ctx, span := r.db.StartSpan(ctx, "INSERT", rollsTable, insertRoll)
+	defer func() { database.EndSpan(span, err) }()
+
+	span.SetAttributes(
+		attribute.Int("db.batch.size", len(rolls)),
+		attribute.Int("app.outbox.message_count", len(outbox)),
+	)
+
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, roll := range rolls {
+		args, err := insertArgs(roll)
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.ExecContext(ctx, insertRoll, args...); err != nil {
+			return fmt.Errorf("failed to insert roll: %w", err)
+		}
+	}
+
+	if err = insertOutboxMessages(ctx, tx, outbox); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteRollRepository) FindByID(ctx context.Context, id string) (roll *models.Roll, err error) {
+	ctx, span := r.db.StartSpan(ctx, "SELECT", rollsTable, selectRollBy)
+	defer func() { endSpan(span, err) }()
+
+	roll, err = scanRoll(r.db.DB.QueryRowContext(ctx, selectRollBy, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRollNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to select roll: %w", err)
+	}
+
+	return roll, nil
+}
+
+func (r *SQLiteRollRepository) List(ctx context.Context, filter models.RollFilter) (page *models.RollPage, err error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From.UnixNano())
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To.UnixNano())
+	}
+	if filter.Result != nil {
+		conditions = append(conditions, "result = ?")
+		args = append(args, *filter.Result)
+	}
+	if filter.RollerID != "" {
+		conditions = append(conditions, "roller_id = ?")
+		args = append(args, filter.RollerID)
+	}
+	if filter.SessionID != "" {
+		conditions = append(conditions, "session_id = ?")
+		args = append(args, filter.SessionID)
+	}
+	if filter.Cursor != "" {
+		createdAt, id, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, "(created_at < ? OR (created_at = ? AND id < ?))")
+		args = append(args, createdAt, createdAt, id)
+	}
+
+	query := selectRolls
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	// Fetch one extra row to know whether there is a next page
+	args = append(args, filter.Limit+1)
+
+	ctx, span := r.db.StartSpan(ctx, "SELECT", rollsTable, query)
+	defer func() { database.EndSpan(span, err) }()
+
+	rows, err := r.db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select rolls: %w", err)
+	}
+	defer rows.Close()
+
+	page = &models.RollPage{Rolls: []models.Roll{}}
+	for rows.Next() {
+		roll, err := scanRoll(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan roll: %w", err)
+		}
+		page.Rolls = append(page.Rolls, *roll)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rolls: %w", err)
+	}
+
+	if len(page.Rolls) > filter.Limit {
+		page.Rolls = page.Rolls[:filter.Limit]
+		last := page.Rolls[len(page.Rolls)-1]
+		page.NextCursor = encodeCursor(last.CreatedAt.UnixNano(), last.ID)
+	}
+
+	return page, nil
+}
+
+func insertArgs(roll *models.Roll) ([]interface{}, error) {
+	terms, err := json.Marshal(roll.Terms)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal roll terms: %w", err)
+	}
+
+	var serverSeedID, serverSeedHash, clientSeed sql.NullString
+	var nonce sql.NullInt64
+	if roll.Fairness != nil {
+		serverSeedID = sql.NullString{String: roll.Fairness.ServerSeedID, Valid: true}
+		serverSeedHash = sql.NullString{String: roll.Fairness.ServerSeedHash, Valid: true}
+		clientSeed = sql.NullString{String: roll.Fairness.ClientSeed, Valid: true}
+		nonce = sql.NullInt64{Int64: roll.Fairness.Nonce, Valid: true}
+	}
+
+	return []interface{}{
+		roll.ID,
+		roll.Expression,
+		string(terms),
+		roll.Result,
+		roll.RollerID,
+		roll.CreatedAt.UnixNano(),
+		serverSeedID,
+		serverSeedHash,
+		clientSeed,
+		nonce,
+		roll.SessionID,
+	}, nil
+}
+
+func (r *SQLiteRollRepository) Each(ctx context.Context, from, to time.Time, fn func(*models.Roll) error) (err error) {
+	ctx, span := r.db.StartSpan(ctx, "SELECT", rollsTable, selectWindow)
+	defer func() { database.EndSpan(span, err) }()
+
+	rows, err := r.db.DB.QueryContext(ctx, selectWindow, from.UnixNano(), to.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to select rolls: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		roll, err := scanRoll(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan roll: %w", err)
+		}
+		if err := fn(roll); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRoll(row rowScanner) (*models.Roll, error) {
+	var (
+		roll           models.Roll
+		terms          string
+		createdAt      int64
+		serverSeedID   sql.NullString
+		serverSeedHash sql.NullString
+		clientSeed     sql.NullString
+		nonce          sql.NullInt64
+	)
+
+	if err := row.Scan(&roll.ID, &roll.Expression, &terms, &roll.Result, &roll.RollerID, &createdAt, &serverSeedID, &serverSeedHash, &clientSeed, &nonce, &roll.SessionID); err != nil {
+		return nil, err
+	}
+
+	if serverSeedID.Valid {
+		roll.Fairness = &models.Fairness{
+			ServerSeedID:   serverSeedID.String,
+			ServerSeedHash: serverSeedHash.String,
+			ClientSeed:     clientSeed.String,
+			Nonce:          nonce.Int64,
+		}
+	}
+
+	if err := json.Unmarshal([]byte(terms), &roll.Terms); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal roll terms: %w", err)
+	}
+	roll.CreatedAt = time.Unix(0, createdAt).UTC()
+
+	return &roll, nil
+}
+
+func encodeCursor(createdAt int64, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(createdAt, 10) + "|" + id))
+}
+
+func decodeCursor(cursor string) (int64, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return 0, "", ErrInvalidCursor
+	}
+
+	value, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+
+	return value, id, nil
+}
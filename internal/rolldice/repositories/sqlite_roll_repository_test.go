@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/models"
+	"github.com/demo/rolldice/pkg/database"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		createdAt int64
+		id        string
+	}{
+		{0, "a"},
+		{1718000000123456789, "01J0ABCDEF"},
+		{-5, "negative"},
+		{42, "with|separator"},
+	}
+
+	for _, test := range tests {
+		createdAt, id, err := decodeCursor(encodeCursor(test.createdAt, test.id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if createdAt != test.createdAt || id != test.id {
+			t.Errorf("round trip of (%d, %q) = (%d, %q)", test.createdAt, test.id, createdAt, id)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsInvalidCursors(t *testing.T) {
+	for _, cursor := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("no separator")),
+		base64.RawURLEncoding.EncodeToString([]byte("yesterday|id")),
+	} {
+		if _, _, err := decodeCursor(cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("decodeCursor(%q) error = %v, want ErrInvalidCursor", cursor, err)
+		}
+	}
+}
+
+func TestListPaginates(t *testing.T) {
+	repository := newRollRepository(t)
+	ctx := context.Background()
+	start := time.Unix(1700000000, 0).UTC()
+
+	// b and c share a timestamp, the id breaks the tie
+	rolls := []*models.Roll{
+		{ID: "a", RollerID: "ada", CreatedAt: start},
+		{ID: "b", RollerID: "ada", CreatedAt: start.Add(time.Second)},
+		{ID: "c", RollerID: "bob", CreatedAt: start.Add(time.Second)},
+		{ID: "d", RollerID: "ada", CreatedAt: start.Add(2 * time.Second)},
+		{ID: "e", RollerID: "ada", CreatedAt: start.Add(3 * time.Second)},
+	}
+	if err := repository.CreateMany(ctx, rolls); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter models.RollFilter
+		pages  [][]string
+	}{
+		{"pages of two", models.RollFilter{Limit: 2}, [][]string{{"e", "d"}, {"c", "b"}, {"a"}}},
+		{"exact last page", models.RollFilter{Limit: 5}, [][]string{{"e", "d", "c", "b", "a"}}},
+		{"filtered", models.RollFilter{RollerID: "ada", Limit: 3}, [][]string{{"e", "d", "b"}, {"a"}}},
+		{"window", models.RollFilter{From: timePtr(start.Add(time.Second)), To: timePtr(start.Add(3 * time.Second)), Limit: 1}, [][]string{{"d"}, {"c"}, {"b"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter := test.filter
+			pages := [][]string{}
+
+			for {
+				page, err := repository.List(ctx, filter)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				ids := []string{}
+				for _, roll := range page.Rolls {
+					ids = append(ids, roll.ID)
+				}
+				pages = append(pages, ids)
+
+				if page.NextCursor == "" || len(pages) > len(test.pages) {
+					break
+				}
+				filter.Cursor = page.NextCursor
+			}
+
+			if !reflect.DeepEqual(pages, test.pages) {
+				t.Errorf("pages = %v, want %v", pages, test.pages)
+			}
+		})
+	}
+}
+
+func newRollRepository(t *testing.T) *SQLiteRollRepository {
+	t.Helper()
+
+	db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "rolldice.db"), noop.NewTracerProvider().Tracer(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	repository, err := NewSQLiteRollRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return repository
+}
+
+func timePtr(value time.Time) *time.Time {
+	return &value
+}
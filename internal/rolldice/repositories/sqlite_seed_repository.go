@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/models"
+	"github.com/demo/rolldice/pkg/database"
+)
+
+const (
+	seedsTable      = "server_seeds"
+	seedNoncesTable = "server_seed_nonces"
+)
+
+var seedMigrations = []database.Migration{
+	{Name: "server_seeds_001_create", Statement: createSeedsTable},
+	{Name: "server_seeds_002_nonces", Statement: createSeedNoncesTable},
+}
+
+const createSeedsTable = `CREATE TABLE IF NOT EXISTS server_seeds (
+	id          TEXT PRIMARY KEY,
+	seed        TEXT NOT NULL,
+	hash        TEXT NOT NULL,
+	nonce       INTEGER NOT NULL DEFAULT 0,
+	created_at  INTEGER NOT NULL,
+	revealed_at INTEGER
+);
+CREATE INDEX IF NOT EXISTS server_seeds_active_idx ON server_seeds (revealed_at, created_at DESC);`
+
+// A client seed and nonce pair rolls the same outcome every time under a seed, so each pair is used once
+const createSeedNoncesTable = `CREATE TABLE IF NOT EXISTS server_seed_nonces (
+	server_seed_id TEXT NOT NULL,
+	client_seed    TEXT NOT NULL,
+	nonce          INTEGER NOT NULL,
+	PRIMARY KEY (server_seed_id, client_seed, nonce)
+);`
+
+const (
+	insertSeed     = `INSERT INTO server_seeds (id, seed, hash, created_at) VALUES (?, ?, ?, ?)`
+	selectSeeds    = `SELECT id, seed, hash, created_at, revealed_at FROM server_seeds`
+	selectSeedBy   = selectSeeds + ` WHERE id = ?`
+	selectActive   = selectSeeds + ` WHERE revealed_at IS NULL ORDER BY created_at DESC LIMIT 1`
+	revealSeed     = `UPDATE server_seeds SET revealed_at = ? WHERE revealed_at IS NULL`
+	incrementNonce = `UPDATE server_seeds SET nonce = nonce + 1 WHERE id = ? AND revealed_at IS NULL RETURNING nonce`
+	claimNonce     = `INSERT INTO server_seed_nonces (server_seed_id, client_seed, nonce) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`
+)
+
+type SQLiteSeedRepository struct {
+	db *database.SQLite
+}
+
+func NewSQLiteSeedRepository(db *database.SQLite) (*SQLiteSeedRepository, error) {
+	if err := db.Migrate(context.Background(), seedMigrations); err != nil {
+		return nil, fmt.Errorf("failed to migrate server_seeds table: %w", err)
+	}
+
+	return &SQLiteSeedRepository{db}, nil
+}
+
+func (r *SQLiteSeedRepository) Active(ctx context.Context) (seed *models.ServerSeed, err error) {
+	ctx, span := r.db.StartSpan(ctx, "SELECT", seedsTable, selectActive)
+	defer func() { endSpan(span, err) }()
+
+	return r.scan(r.db.DB.QueryRowContext(ctx, selectActive))
+}
+
+func (r *SQLiteSeedRepository) FindByID(ctx context.Context, id string) (seed *models.ServerSeed, err error) {
+	ctx, span := r.db.StartSpan(ctx, "SELECT", seedsTable, selectSeedBy)
+	defer func() { endSpan(span, err) }()
+
+	return r.scan(r.db.DB.QueryRowContext(ctx, selectSeedBy, id))
+}
+
+func (r *SQLiteSeedRepository) Create(ctx context.Context, seed *models.ServerSeed) (err error) {
+	ctx, span := r.db.StartSpan(ctx, "INSERT", seedsTable, insertSeed)
+	defer func() { database.EndSpan(span, err) }()
+
+	if _, err = r.db.DB.ExecContext(ctx, insertSeed, seed.ID, seed.Seed, seed.Hash, seed.CreatedAt.UnixNano()); err != nil {
+		return fmt.Errorf("failed to insert server seed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteSeedRepository) Rotate(ctx context.Context, next *models.ServerSeed) (revealed *models.ServerSeed, err error) {
+	ctx, span := r.db.StartSpan(ctx, "UPDATE", seedsTable, revealSeed)
+	defer func() { endSpan(span, err) }()
+
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	revealed, err = r.scan(tx.QueryRowContext(ctx, selectActive))
+	if err != nil {
+		return nil, err
+	}
+
+	revealedAt := time.Now().UTC()
+	if _, err = tx.ExecContext(ctx, revealSeed, revealedAt.UnixNano()); err != nil {
+		return nil, fmt.Errorf("failed to reveal server seed: %w", err)
+	}
+	revealed.RevealedAt = &revealedAt
+
+	if _, err = tx.ExecContext(ctx, insertSeed, next.ID, next.Seed, next.Hash, next.CreatedAt.UnixNano()); err != nil {
+		return nil, fmt.Errorf("failed to insert server seed: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return revealed, nil
+}
+
+func (r *SQLiteSeedRepository) NextNonce(ctx context.Context, id string) (nonce int64, err error) {
+	ctx, span := r.db.StartSpan(ctx, "UPDATE", seedsTable, incrementNonce)
+	defer func() { endSpan(span, err) }()
+
+	err = r.db.DB.QueryRowContext(ctx, incrementNonce, id).Scan(&nonce)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrSeedNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment nonce: %w", err)
+	}
+
+	return nonce, nil
+}
+
+func (r *SQLiteSeedRepository) ClaimNonce(ctx context.Context, id, clientSeed string, nonce int64) (claimed bool, err error) {
+	ctx, span := r.db.StartSpan(ctx, "INSERT", seedNoncesTable, claimNonce)
+	defer func() { database.EndSpan(span, err) }()
+
+	result, err := r.db.DB.ExecContext(ctx, claimNonce, id, clientSeed, nonce)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim nonce: %w", err)
+	}
+
+	inserted, _ := result.RowsAffected()
+
+	return inserted > 0, nil
+}
+
+func (r *SQLiteSeedRepository) scan(row *sql.Row) (*models.ServerSeed, error) {
+	var (
+		seed       models.ServerSeed
+		createdAt  int64
+		revealedAt sql.NullInt64
+	)
+
+	err := row.Scan(&seed.ID, &seed.Seed, &seed.Hash, &createdAt, &revealedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSeedNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to select server seed: %w", err)
+	}
+
+	seed.CreatedAt = time.Unix(0, createdAt).UTC()
+	if revealedAt.Valid {
+		value := time.Unix(0, revealedAt.Int64).UTC()
+		seed.RevealedAt = &value
+	}
+
+	return &seed, nil
+}
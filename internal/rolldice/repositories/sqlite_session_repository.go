@@ -0,0 +1,294 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/models"
+	"github.com/demo/rolldice/pkg/database"
+)
+
+const sessionsTable = "sessions"
+
+var sessionMigrations = []database.Migration{
+	{Name: "sessions_001_create", Statement: createSessionsTables},
+	{Name: "sessions_002_player_subject", Statement: addPlayerSubject},
+}
+
+const createSessionsTables = `CREATE TABLE IF NOT EXISTS sessions (
+	id          TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	expression  TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	max_rounds  INTEGER NOT NULL DEFAULT 0,
+	turn        INTEGER NOT NULL DEFAULT 0,
+	version     INTEGER NOT NULL DEFAULT 0,
+	created_at  INTEGER NOT NULL,
+	updated_at  INTEGER NOT NULL,
+	finished_at INTEGER
+);
+CREATE TABLE IF NOT EXISTS session_players (
+	id         TEXT PRIMARY KEY,
+	session_id TEXT NOT NULL REFERENCES sessions (id),
+	name       TEXT NOT NULL,
+	score      INTEGER NOT NULL DEFAULT 0,
+	rolls      INTEGER NOT NULL DEFAULT 0,
+	joined_at  INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS session_players_session_id_idx ON session_players (session_id, joined_at);`
+
+const addPlayerSubject = `ALTER TABLE session_players ADD COLUMN subject TEXT NOT NULL DEFAULT '';`
+
+const (
+	insertSession = `INSERT INTO sessions (id, name, expression, status, max_rounds, turn, version, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	selectSession = `SELECT id, name, expression, status, max_rounds, turn, version, created_at, updated_at, finished_at FROM sessions WHERE id = ?`
+	selectPlayers = `SELECT id, name, subject, score, rolls, joined_at FROM session_players WHERE session_id = ? ORDER BY joined_at ASC, id ASC`
+	insertPlayer  = `INSERT INTO session_players (id, session_id, name, subject, joined_at) VALUES (?, ?, ?, ?, ?)`
+	updateSession = `UPDATE sessions SET status = ?, turn = ?, version = version + 1, updated_at = ?, finished_at = ? WHERE id = ? AND version = ?`
+	updatePlayer  = `UPDATE session_players SET score = ?, rolls = ? WHERE id = ?`
+	touchSession  = `UPDATE sessions SET version = version + 1, updated_at = ? WHERE id = ? AND version = ?`
+)
+
+type SQLiteSessionRepository struct {
+	db *database.SQLite
+}
+
+func NewSQLiteSessionRepository(db *database.SQLite) (*SQLiteSessionRepository, error) {
+	if err := db.Migrate(context.Background(), sessionMigrations); err != nil {
+		return nil, fmt.Errorf("failed to migrate sessions tables: %w", err)
+	}
+
+	// Session changes write rolls and outbox messages in their transactions
+	if err := db.Migrate(context.Background(), rollMigrations); err != nil {
+		return nil, fmt.Errorf("failed to migrate rolls table: %w", err)
+	}
+
+	if err := db.Migrate(context.Background(), outboxMigrations); err != nil {
+		return nil, fmt.Errorf("failed to migrate outbox table: %w", err)
+	}
+
+	return &SQLiteSessionRepository{db}, nil
+}
+
+func (r *SQLiteSessionRepository) Create(ctx context.Context, session *models.Session, outbox ...*models.OutboxMessage) (err error) {
+	ctx, span := r.db.StartSpan(ctx, "INSERT", sessionsTable, insertSession)
+	defer func() { database.EndSpan(span, err) }()
+
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, insertSession,
+		session.ID,
+		session.Name,
+		session.Expression,
+		string(session.Status),
+		session.MaxRounds,
+		session.Turn,
+		session.Version,
+		session.CreatedAt.UnixNano(),
+		session.UpdatedAt.UnixNano(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert session: %w", err)
+	}
+
+	if err = insertOutboxMessages(ctx, tx, outbox); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SQLiteSessionRepository) FindByID(ctx context.Context, id string) (session *models.Session, err error) {
+	ctx, span := r.db.StartSpan(ctx, "SELECT", sessionsTable, selectSession)
+	defer func() { endSpan(span, err) }()
+
+	var (
+		status     string
+		createdAt  int64
+		updatedAt  int64
+		finishedAt sql.NullInt64
+	)
+
+	session = &models.Session{}
+	err = r.db.DB.QueryRowContext(ctx, selectSession, id).Scan(
+		&session.ID,
+		&session.Name,
+		&session.Expression,
+		&status,
+		&session.MaxRounds,
+		&session.Turn,
+		&session.Version,
+		&createdAt,
+		&updatedAt,
+		&finishedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to select session: %w", err)
+	}
+
+	session.Status = models.SessionStatus(status)
+	session.CreatedAt = time.Unix(0, createdAt).UTC()
+	session.UpdatedAt = time.Unix(0, updatedAt).UTC()
+	if finishedAt.Valid {
+		value := time.Unix(0, finishedAt.Int64).UTC()
+		session.FinishedAt = &value
+	}
+
+	session.Players, err = r.players(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (r *SQLiteSessionRepository) players(ctx context.Context, sessionID string) (players []models.SessionPlayer, err error) {
+	ctx, span := r.db.StartSpan(ctx, "SELECT", "session_players", selectPlayers)
+	defer func() { database.EndSpan(span, err) }()
+
+	rows, err := r.db.DB.QueryContext(ctx, selectPlayers, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select session players: %w", err)
+	}
+	defer rows.Close()
+
+	players = []models.SessionPlayer{}
+	for rows.Next() {
+		var (
+			player   models.SessionPlayer
+			joinedAt int64
+		)
+		if err := rows.Scan(&player.ID, &player.Name, &player.Subject, &player.Score, &player.Rolls, &joinedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan session player: %w", err)
+		}
+		player.JoinedAt = time.Unix(0, joinedAt).UTC()
+		players = append(players, player)
+	}
+
+	return players, rows.Err()
+}
+
+func (r *SQLiteSessionRepository) AddPlayer(ctx context.Context, session *models.Session, player *models.SessionPlayer, outbox ...*models.OutboxMessage) (err error) {
+	ctx, span := r.db.StartSpan(ctx, "INSERT", "session_players", insertPlayer)
+	defer func() { database.EndSpan(span, err) }()
+
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Bumping the version keeps a join from racing with the first roll of the session
+	if err = checkVersion(tx.ExecContext(ctx, touchSession, player.JoinedAt.UnixNano(), session.ID, session.Version)); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, insertPlayer, player.ID, session.ID, player.Name, player.Subject, player.JoinedAt.UnixNano()); err != nil {
+		return fmt.Errorf("failed to insert session player: %w", err)
+	}
+
+	if err = insertOutboxMessages(ctx, tx, outbox); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	session.Version++
+	session.UpdatedAt = player.JoinedAt
+	session.Players = append(session.Players, *player)
+
+	return nil
+}
+
+func (r *SQLiteSessionRepository) Save(ctx context.Context, session *models.Session, outbox ...*models.OutboxMessage) (err error) {
+	ctx, span := r.db.StartSpan(ctx, "UPDATE", sessionsTable, updateSession)
+	defer func() { database.EndSpan(span, err) }()
+
+	return r.save(ctx, session, nil, outbox)
+}
+
+func (r *SQLiteSessionRepository) SaveRoll(ctx context.Context, session *models.Session, roll *models.Roll, outbox ...*models.OutboxMessage) (err error) {
+	ctx, span := r.db.StartSpan(ctx, "UPDATE", sessionsTable, updateSession)
+	defer func() { database.EndSpan(span, err) }()
+
+	return r.save(ctx, session, roll, outbox)
+}
+
+func (r *SQLiteSessionRepository) save(ctx context.Context, session *models.Session, roll *models.Roll, outbox []*models.OutboxMessage) error {
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var finishedAt sql.NullInt64
+	if session.FinishedAt != nil {
+		finishedAt = sql.NullInt64{Int64: session.FinishedAt.UnixNano(), Valid: true}
+	}
+
+	if err = checkVersion(tx.ExecContext(ctx, updateSession,
+		string(session.Status),
+		session.Turn,
+		session.UpdatedAt.UnixNano(),
+		finishedAt,
+		session.ID,
+		session.Version,
+	)); err != nil {
+		return err
+	}
+
+	for _, player := range session.Players {
+		if _, err = tx.ExecContext(ctx, updatePlayer, player.Score, player.Rolls, player.ID); err != nil {
+			return fmt.Errorf("failed to update session player: %w", err)
+		}
+	}
+
+	if roll != nil {
+		args, err := insertArgs(roll)
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.ExecContext(ctx, insertRoll, args...); err != nil {
+			return fmt.Errorf("failed to insert roll: %w", err)
+		}
+	}
+
+	if err = insertOutboxMessages(ctx, tx, outbox); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	session.Version++
+
+	return nil
+}
+
+func checkVersion(result sql.Result, err error) error {
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	if updated, _ := result.RowsAffected(); updated == 0 {
+		return ErrSessionConflict
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/demo/rolldice/pkg/database"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// endSpan ends a storage span, a missing record is an expected outcome rather than a span error
+func endSpan(span trace.Span, err error) {
+	if errors.Is(err, ErrRollNotFound) || errors.Is(err, ErrSeedNotFound) || errors.Is(err, ErrSessionNotFound) {
+		err = nil
+	}
+	database.EndSpan(span, err)
+}
@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/demo/rolldice/internal/rolldice/auth"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// apiKeyMetadata is the gRPC counterpart of the X-API-Key header, the gateway forwards one as the other
+const apiKeyMetadata = "x-api-key"
+
+// AuthUnaryInterceptor rejects anonymous calls with Unauthenticated, like the REST API does with 401
+func AuthUnaryInterceptor(authenticator *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, authenticator)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, request)
+	}
+}
+
+func AuthStreamInterceptor(authenticator *auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(server interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(stream.Context(), authenticator)
+		if err != nil {
+			return err
+		}
+		return handler(server, &authenticatedStream{stream, ctx})
+	}
+}
+
+func authenticate(ctx context.Context, authenticator *auth.Authenticator) (context.Context, error) {
+	if !authenticator.Enabled() {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	span := trace.SpanFromContext(ctx)
+
+	identity, err := authenticator.Authenticate(first(md, apiKeyMetadata), first(md, "authorization"))
+
+	if err != nil {
+		span.SetAttributes(attribute.String("app.auth.error", err.Error()))
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	span.SetAttributes(
+		semconv.EnduserID(identity.Subject),
+		attribute.String("app.auth.method", identity.Method),
+	)
+
+	return auth.WithIdentity(ctx, identity), nil
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// forwardAPIKey passes X-API-Key on to the gRPC server, the gateway only forwards Authorization by default
+func forwardAPIKey(header string) (string, bool) {
+	if strings.EqualFold(header, apiKeyMetadata) {
+		return apiKeyMetadata, true
+	}
+	return runtime.DefaultHeaderMatcher(header)
+}
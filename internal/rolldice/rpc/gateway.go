@@ -0,0 +1,41 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	rolldicev1 "github.com/demo/rolldice/pkg/pb/rolldice/v1"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+)
+
+// GatewayPrefix is where the REST mapping of the gRPC API is mounted on echo, see proto/rolldice/v1
+const GatewayPrefix = "/rpc"
+
+const watchSuffix = ":watch"
+
+// InitGatewayHandler serves the REST mapping of RollDiceService on echo by calling the gRPC server over conn
+func InitGatewayHandler(ctx context.Context, e *echo.Echo, conn *grpc.ClientConn) error {
+	mux := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(forwardAPIKey))
+
+	if err := rolldicev1.RegisterRollDiceServiceHandler(ctx, mux, conn); err != nil {
+		return err
+	}
+
+	e.Any(GatewayPrefix+"/*", func(c echo.Context) error {
+		// WatchRolls streams for as long as the client listens, past SERVER_READ_TIMEOUT and SERVER_WRITE_TIMEOUT
+		if strings.HasSuffix(c.Request().URL.Path, watchSuffix) {
+			controller := http.NewResponseController(c.Response())
+			controller.SetReadDeadline(time.Time{})
+			controller.SetWriteDeadline(time.Time{})
+		}
+
+		mux.ServeHTTP(c.Response(), c.Request())
+		return nil
+	})
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/demo/rolldice/internal/rolldice/models"
+	"github.com/demo/rolldice/internal/rolldice/repositories"
+	"github.com/demo/rolldice/internal/rolldice/services"
+	exception "github.com/demo/rolldice/pkg/exceptions"
+	rolldicev1 "github.com/demo/rolldice/pkg/pb/rolldice/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RollDiceServer exposes services.RollDiceService over gRPC, the REST gateway calls it through the same server
+type RollDiceServer struct {
+	rolldicev1.UnimplementedRollDiceServiceServer
+	rolldiceService *services.RollDiceService
+	streamService   *services.StreamService
+}
+
+func InitRollDiceServer(s *grpc.Server, rolldiceService *services.RollDiceService, streamService *services.StreamService) {
+	rolldicev1.RegisterRollDiceServiceServer(s, &RollDiceServer{
+		rolldiceService: rolldiceService,
+		streamService:   streamService,
+	})
+}
+
+func (s *RollDiceServer) Roll(ctx context.Context, request *rolldicev1.RollRequest) (*rolldicev1.RollResponse, error) {
+	if request.Nonce != nil && request.GetNonce() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "nonce must be a non-negative integer")
+	}
+
+	roll, err := s.rolldiceService.Dice(ctx, services.RollRequest{
+		Expression: request.GetExpression(),
+		RollerID:   request.GetRollerId(),
+		ClientSeed: request.GetClientSeed(),
+		Nonce:      request.Nonce,
+	})
+
+	if err != nil {
+		return nil, rpcError(err)
+	}
+
+	return &rolldicev1.RollResponse{Roll: toDiceRoll(roll)}, nil
+}
+
+func (s *RollDiceServer) WatchRolls(request *rolldicev1.WatchRollsRequest, server rolldicev1.RollDiceService_WatchRollsServer) error {
+	ctx := server.Context()
+
+	filter := services.StreamFilter{
+		SessionID: request.GetSessionId(),
+		RollerID:  request.GetRollerId(),
+	}
+
+	stream, err := s.streamService.Subscribe(ctx, "grpc", filter, request.GetLastEventId())
+
+	if errors.Is(err, repositories.ErrRollNotFound) {
+		return status.Error(codes.InvalidArgument, "unknown last_event_id")
+	}
+	if err != nil {
+		return rpcError(err)
+	}
+
+	for {
+		select {
+		case roll, ok := <-stream.C:
+			if !ok {
+				err := stream.Err()
+				stream.Close(nil)
+				if err != nil {
+					return status.Error(codes.Unavailable, err.Error())
+				}
+				return nil
+			}
+
+			if err := server.Send(&rolldicev1.WatchRollsResponse{Roll: toDiceRoll(roll)}); err != nil {
+				stream.Close(err)
+				return err
+			}
+			stream.Sent()
+		case <-ctx.Done():
+			stream.Close(nil)
+			return nil
+		}
+	}
+}
+
+var categoryCodes = map[exception.Category]codes.Code{
+	exception.CategoryValidation:      codes.InvalidArgument,
+	exception.CategoryUnauthenticated: codes.Unauthenticated,
+	exception.CategoryForbidden:       codes.PermissionDenied,
+	exception.CategoryNotFound:        codes.NotFound,
+	exception.CategoryConflict:        codes.Aborted,
+	exception.CategoryGone:            codes.OutOfRange,
+	exception.CategoryUnprocessable:   codes.FailedPrecondition,
+	exception.CategoryRateLimited:     codes.ResourceExhausted,
+	exception.CategoryUnavailable:     codes.Unavailable,
+	exception.CategoryInternal:        codes.Internal,
+}
+
+// rpcError maps domain errors to status codes like HTTPErrorHandler maps them to HTTP statuses, internals stay private
+func rpcError(err error) error {
+	exc := exception.From(err)
+
+	code, ok := categoryCodes[exc.Category]
+	if !ok {
+		code = codes.Internal
+	}
+
+	return status.Error(code, exc.Message)
+}
+
+func toDiceRoll(roll *models.Roll) *rolldicev1.DiceRoll {
+	result := &rolldicev1.DiceRoll{
+		Id:         roll.ID,
+		Expression: roll.Expression,
+		Result:     int32(roll.Result),
+		RollerId:   roll.RollerID,
+		SessionId:  roll.SessionID,
+		CreatedAt:  timestamppb.New(roll.CreatedAt),
+	}
+
+	for _, term := range roll.Terms {
+		t := &rolldicev1.Term{
+			Notation: term.Notation,
+			Sign:     int32(term.Sign),
+			Sides:    int32(term.Sides),
+			Subtotal: int32(term.Subtotal),
+		}
+		for _, die := range term.Dice {
+			d := &rolldicev1.Die{
+				Value:    int32(die.Value),
+				Kept:     die.Kept,
+				Exploded: die.Exploded,
+			}
+			for _, value := range die.Rerolled {
+				d.Rerolled = append(d.Rerolled, int32(value))
+			}
+			t.Dice = append(t.Dice, d)
+		}
+		result.Terms = append(result.Terms, t)
+	}
+
+	if roll.Fairness != nil {
+		result.Fairness = &rolldicev1.Fairness{
+			ServerSeedId:   roll.Fairness.ServerSeedID,
+			ServerSeedHash: roll.Fairness.ServerSeedHash,
+			ClientSeed:     roll.Fairness.ClientSeed,
+			Nonce:          roll.Fairness.Nonce,
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/dice"
+	"github.com/demo/rolldice/internal/rolldice/models"
+	"github.com/demo/rolldice/internal/rolldice/random"
+	"github.com/demo/rolldice/internal/rolldice/repositories"
+	exception "github.com/demo/rolldice/pkg/exceptions"
+	"github.com/demo/rolldice/pkg/idgen"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const serverSeedSize = 32
+
+var (
+	ErrRollNotProvablyFair = exception.New("fairness.not_provably_fair", exception.CategoryUnprocessable, "roll was not made with a client seed")
+	ErrNonceUsed           = exception.New("fairness.nonce_used", exception.CategoryConflict, "nonce was already used with this client seed")
+)
+
+// FairnessService commits to a hashed server seed before rolling and reveals it on rotation,
+// so players can recompute HMAC-SHA256(serverSeed, "clientSeed:nonce:round") for every past roll
+type FairnessService struct {
+	tracer      trace.Tracer
+	logger      *logrus.Logger
+	repository  repositories.SeedRepository
+	idGenerator idgen.Generator
+	// Rolls hold the read lock while using the active seed, so it cannot be revealed mid-roll
+	rotation sync.RWMutex
+}
+
+func NewFairnessService(tracer trace.Tracer, logger *logrus.Logger, repository repositories.SeedRepository, idGenerator idgen.Generator) *FairnessService {
+	return &FairnessService{
+		tracer:      tracer,
+		logger:      logger,
+		repository:  repository,
+		idGenerator: idGenerator,
+	}
+}
+
+// Commitment returns the hash of the active server seed, creating the first seed when needed
+func (s *FairnessService) Commitment(ctx context.Context) (*models.ServerSeed, error) {
+	seed, err := s.readActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.rotation.RUnlock()
+
+	public := seed.Public()
+	return &public, nil
+}
+
+func (s *FairnessService) Seed(ctx context.Context, id string) (*models.ServerSeed, error) {
+	seed, err := s.repository.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	public := seed.Public()
+	return &public, nil
+}
+
+// Rotate reveals the active server seed and commits to a new one
+func (s *FairnessService) Rotate(ctx context.Context) (*models.ServerSeed, *models.ServerSeed, error) {
+	ctx, span := s.tracer.Start(ctx, "Rotate server seed")
+	defer span.End()
+
+	s.rotation.Lock()
+	defer s.rotation.Unlock()
+
+	if _, err := s.active(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	next, err := s.newSeed()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	revealed, err := s.repository.Rotate(ctx, next)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	span.SetAttributes(
+		attribute.String("app.fairness.revealed_seed_id", revealed.ID),
+		attribute.String("app.fairness.server_seed_id", next.ID),
+	)
+
+	s.logger.WithContext(ctx).Infof("Server seed %s revealed, %s is now active", revealed.ID, next.ID)
+
+	current := next.Public()
+	return revealed, &current, nil
+}
+
+// Source returns the HMAC random source for a roll, release must be called once the roll is done.
+// A client nonce is rejected once used with clientSeed, replaying it would repeat a known outcome
+func (s *FairnessService) Source(ctx context.Context, clientSeed string, nonce *int64) (*random.HMACSource, *models.Fairness, func(), error) {
+	seed, err := s.readActive(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	fairness := &models.Fairness{
+		ServerSeedID:   seed.ID,
+		ServerSeedHash: seed.Hash,
+		ClientSeed:     clientSeed,
+	}
+
+	fairness.Nonce, err = s.claimNonce(ctx, seed.ID, clientSeed, nonce)
+	if err != nil {
+		s.rotation.RUnlock()
+		return nil, nil, nil, err
+	}
+
+	serverSeed, err := hex.DecodeString(seed.Seed)
+	if err != nil {
+		s.rotation.RUnlock()
+		return nil, nil, nil, fmt.Errorf("corrupt server seed %s: %w", seed.ID, err)
+	}
+
+	return random.NewHMACSource(serverSeed, clientSeed, fairness.Nonce), fairness, s.rotation.RUnlock, nil
+}
+
+// Verify recomputes a roll from its revealed server seed, client seed and nonce
+func (s *FairnessService) Verify(ctx context.Context, roll *models.Roll) (*models.Verification, error) {
+	ctx, span := s.tracer.Start(ctx, "Verify roll")
+	defer span.End()
+
+	if roll.Fairness == nil {
+		return nil, ErrRollNotProvablyFair
+	}
+
+	seed, err := s.repository.FindByID(ctx, roll.Fairness.ServerSeedID)
+	if err != nil {
+		return nil, err
+	}
+
+	verification := &models.Verification{
+		RollID:     roll.ID,
+		Fairness:   *roll.Fairness,
+		Revealed:   seed.RevealedAt != nil,
+		Expected:   roll.Result,
+		RevealedAt: seed.RevealedAt,
+	}
+
+	span.SetAttributes(
+		attribute.String("app.roll.id", roll.ID),
+		attribute.Bool("app.fairness.revealed", verification.Revealed),
+	)
+
+	// An unrevealed seed stays secret, verification becomes possible after the next rotation
+	if !verification.Revealed {
+		return verification, nil
+	}
+
+	verification.ServerSeed = seed.Seed
+
+	serverSeed, err := hex.DecodeString(seed.Seed)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt server seed %s: %w", seed.ID, err)
+	}
+
+	expr, err := dice.Parse(roll.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	source := random.NewHMACSource(serverSeed, roll.Fairness.ClientSeed, roll.Fairness.Nonce)
+	result, err := dice.NewEvaluator(s.tracer, source).Evaluate(ctx, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	verification.Recomputed = &result.Total
+	verification.Verified = hashSeed(serverSeed) == roll.Fairness.ServerSeedHash &&
+		result.Total == roll.Result &&
+		equalValues(result.Values(), (&dice.Result{Terms: roll.Terms}).Values())
+
+	span.SetAttributes(attribute.Bool("app.fairness.verified", verification.Verified))
+
+	return verification, nil
+}
+
+// claimNonce skips server nonces a client already used with clientSeed
+func (s *FairnessService) claimNonce(ctx context.Context, seedID, clientSeed string, nonce *int64) (int64, error) {
+	if nonce != nil {
+		claimed, err := s.repository.ClaimNonce(ctx, seedID, clientSeed, *nonce)
+		if err != nil {
+			return 0, err
+		}
+		if !claimed {
+			return 0, ErrNonceUsed
+		}
+		return *nonce, nil
+	}
+
+	for {
+		next, err := s.repository.NextNonce(ctx, seedID)
+		if err != nil {
+			return 0, err
+		}
+
+		claimed, err := s.repository.ClaimNonce(ctx, seedID, clientSeed, next)
+		if err != nil || claimed {
+			return next, err
+		}
+	}
+}
+
+// readActive returns the active seed with the read lock held, the first seed is created under the write lock
+func (s *FairnessService) readActive(ctx context.Context) (*models.ServerSeed, error) {
+	for {
+		s.rotation.RLock()
+
+		seed, err := s.repository.Active(ctx)
+		if err == nil {
+			return seed, nil
+		}
+
+		s.rotation.RUnlock()
+
+		if !errors.Is(err, repositories.ErrSeedNotFound) {
+			return nil, err
+		}
+
+		s.rotation.Lock()
+		_, err = s.active(ctx)
+		s.rotation.Unlock()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// active creates the first seed when there is none, the caller holds the write lock
+func (s *FairnessService) active(ctx context.Context) (*models.ServerSeed, error) {
+	seed, err := s.repository.Active(ctx)
+	if !errors.Is(err, repositories.ErrSeedNotFound) {
+		return seed, err
+	}
+
+	seed, err = s.newSeed()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Create(ctx, seed); err != nil {
+		return nil, err
+	}
+
+	return seed, nil
+}
+
+func (s *FairnessService) newSeed() (*models.ServerSeed, error) {
+	serverSeed := make([]byte, serverSeedSize)
+	if _, err := rand.Read(serverSeed); err != nil {
+		return nil, fmt.Errorf("failed to generate server seed: %w", err)
+	}
+
+	return &models.ServerSeed{
+		ID:        s.idGenerator.NewID(),
+		Seed:      hex.EncodeToString(serverSeed),
+		Hash:      hashSeed(serverSeed),
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// hashSeed is the public commitment, the hex SHA-256 of the raw seed bytes
+func hashSeed(serverSeed []byte) string {
+	sum := sha256.Sum256(serverSeed)
+	return hex.EncodeToString(sum[:])
+}
+
+func equalValues(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
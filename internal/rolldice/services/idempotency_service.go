@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/auth"
+	"github.com/demo/rolldice/internal/rolldice/models"
+	"github.com/demo/rolldice/internal/rolldice/repositories"
+	exception "github.com/demo/rolldice/pkg/exceptions"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrIdempotencyKeyReused     = exception.New("idempotency.key_reused", exception.CategoryConflict, "idempotency key was already used with different parameters")
+	ErrIdempotencyKeyInProgress = exception.New("idempotency.key_in_progress", exception.CategoryConflict, "a request with this idempotency key is still in progress").WithRetryable(true)
+)
+
+type IdempotencyService struct {
+	tracer         trace.Tracer
+	logger         *logrus.Logger
+	repository     repositories.IdempotencyRepository
+	rollRepository repositories.RollRepository
+	ttl            time.Duration
+}
+
+func NewIdempotencyService(tracer trace.Tracer, logger *logrus.Logger, repository repositories.IdempotencyRepository, rollRepository repositories.RollRepository, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		tracer,
+		logger,
+		repository,
+		rollRepository,
+		ttl,
+	}
+}
+
+// Do runs roll once per caller, key and TTL, later calls of the caller with the same key and request get the stored roll
+// back with replayed set, and the same key with a different request is rejected. Callers never see each other's keys
+func (s *IdempotencyService) Do(ctx context.Context, key string, request RollRequest, roll func(context.Context, RollRequest) (*models.Roll, error)) (result *models.Roll, replayed bool, err error) {
+	ctx, span := s.tracer.Start(ctx, "Idempotent roll")
+	defer span.End()
+
+	subject := auth.Subject(ctx)
+
+	now := time.Now().UTC()
+	reservation := &models.IdempotencyKey{
+		Subject:     subject,
+		Key:         key,
+		RequestHash: hashRollRequest(request),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+
+	existing, err := s.repository.Reserve(ctx, reservation)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if existing != nil {
+		span.SetAttributes(attribute.Bool("app.idempotency.replayed", true))
+
+		if existing.RequestHash != reservation.RequestHash {
+			return nil, false, ErrIdempotencyKeyReused
+		}
+		if existing.RollID == "" {
+			return nil, false, ErrIdempotencyKeyInProgress
+		}
+
+		result, err = s.rollRepository.FindByID(ctx, existing.RollID)
+		if err != nil {
+			return nil, false, err
+		}
+
+		s.logger.WithContext(ctx).Infof("Replayed roll %s for idempotency key", result.ID)
+
+		return result, true, nil
+	}
+
+	result, err = roll(ctx, request)
+	if err != nil {
+		// Let the client retry with the same key, a failed roll was never answered
+		if releaseErr := s.repository.Release(ctx, subject, key); releaseErr != nil {
+			s.logger.WithContext(ctx).WithError(releaseErr).Error("Failed to release idempotency key")
+		}
+		return nil, false, err
+	}
+
+	if err := s.repository.Complete(ctx, subject, key, result.ID); err != nil {
+		// The roll stands, but without its roll ID the key would answer "in progress" until it expires
+		s.logger.WithContext(ctx).WithError(err).Error("Failed to complete idempotency key, releasing it")
+		if releaseErr := s.repository.Release(ctx, subject, key); releaseErr != nil {
+			s.logger.WithContext(ctx).WithError(releaseErr).Error("Failed to release idempotency key")
+		}
+	}
+
+	span.SetAttributes(attribute.Bool("app.idempotency.replayed", false))
+
+	return result, false, nil
+}
+
+func hashRollRequest(request RollRequest) string {
+	if request.Expression == "" {
+		request.Expression = DefaultExpression
+	}
+
+	value, _ := json.Marshal(request)
+	sum := sha256.Sum256(value)
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/models"
+	"github.com/demo/rolldice/internal/rolldice/repositories"
+	"github.com/demo/rolldice/pkg/messaging/kafka"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const outboxMaxBackoff = 5 * time.Minute
+
+// OutboxProducer publishes outbox messages, KafkaProducer implements it
+type OutboxProducer interface {
+	PublishAsync(ctx context.Context, topic string, message kafka.Message) (*kafka.Delivery, error)
+	PublishBatch(ctx context.Context, topic string, messages []kafka.Message) error
+	Transactional() bool
+}
+
+// OutboxRelay publishes outbox messages to Kafka oldest first, a message that fails is retried with exponential backoff.
+// A batch is published in one Kafka transaction and retried whole. Every publish is a new trace linked to the request
+// that wrote the message
+type OutboxRelay struct {
+	tracer     trace.Tracer
+	logger     *logrus.Logger
+	repository repositories.OutboxRepository
+	producer   OutboxProducer
+	interval   time.Duration
+	batchSize  int
+	retention  time.Duration
+	published  metric.Int64Counter
+	failed     metric.Int64Counter
+	wake       chan struct{}
+	stop       chan struct{}
+	stopOnce   sync.Once
+	done       chan struct{}
+}
+
+// NewOutboxRelay polls every interval for up to batchSize due messages, sent messages are kept for retention
+func NewOutboxRelay(tracer trace.Tracer, logger *logrus.Logger, meter metric.Meter, repository repositories.OutboxRepository, producer OutboxProducer, interval time.Duration, batchSize int, retention time.Duration) (*OutboxRelay, error) {
+	published, err := meter.Int64Counter("app.outbox.published", metric.WithDescription("Outbox messages published to Kafka"))
+	if err != nil {
+		return nil, err
+	}
+
+	failed, err := meter.Int64Counter("app.outbox.failed", metric.WithDescription("Failed attempts to publish an outbox message"))
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := meter.Int64ObservableGauge("app.outbox.pending", metric.WithDescription("Outbox messages waiting to be published"))
+	if err != nil {
+		return nil, err
+	}
+
+	relay := &OutboxRelay{
+		tracer:     tracer,
+		logger:     logger,
+		repository: repository,
+		producer:   producer,
+		interval:   interval,
+		batchSize:  batchSize,
+		retention:  retention,
+		published:  published,
+		failed:     failed,
+		wake:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, observer metric.Observer) error {
+		count, err := repository.CountPending(ctx)
+		if err != nil {
+			return err
+		}
+		observer.ObserveInt64(pending, count)
+		return nil
+	}, pending)
+	if err != nil {
+		return nil, err
+	}
+
+	return relay, nil
+}
+
+// Transactional tells whether batches can be published, they need Kafka transactions
+func (r *OutboxRelay) Transactional() bool {
+	return r.producer != nil && r.producer.Transactional()
+}
+
+// Notify wakes the relay up, so a committed message does not wait for the next poll
+func (r *OutboxRelay) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run relays messages until Stop is called
+func (r *OutboxRelay) Run() error {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.relay()
+
+		select {
+		case <-r.stop:
+			return nil
+		case <-ticker.C:
+		case <-r.wake:
+		}
+	}
+}
+
+// Stop waits for the message being published, messages left pending are published after the next start
+func (r *OutboxRelay) Stop(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stop) })
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// relay publishes due messages until none is left or one fails, Kafka or the database is then likely unavailable.
+// A page holds the oldest unsent message of every key, the next ones are due once it is sent
+func (r *OutboxRelay) relay() {
+	ctx := context.Background()
+
+	for {
+		messages, err := r.repository.Pending(ctx, time.Now().UTC(), r.batchSize)
+		if err != nil {
+			r.logger.WithError(err).Error("Failed to read the outbox")
+			return
+		}
+
+		if len(messages) == 0 {
+			break
+		}
+
+		if !r.publish(messages) {
+			return
+		}
+	}
+
+	if _, err := r.repository.DeleteSent(ctx, time.Now().UTC().Add(-r.retention)); err != nil {
+		r.logger.WithError(err).Error("Failed to delete sent outbox messages")
+	}
+}
+
+type outboxPublish struct {
+	ctx      context.Context
+	span     trace.Span
+	message  *models.OutboxMessage
+	delivery *kafka.Delivery
+	err      error
+}
+
+// outboxKey orders messages, those of one key are published in the order they were written
+type outboxKey struct {
+	topic string
+	key   string
+}
+
+// publish hands every message to the producer before waiting for any, so an async producer batches them,
+// and tells whether all of them were published and marked sent. A message whose key already has one in flight
+// waits for it and is held back when it failed. A batch is published on its own, in a transaction
+func (r *OutboxRelay) publish(messages []*models.OutboxMessage) bool {
+	publishes := make([]*outboxPublish, 0, len(messages))
+	inFlight := map[outboxKey]*outboxPublish{}
+	ok := true
+
+	for i := 0; i < len(messages); i++ {
+		if r.stopping() {
+			// The messages handed over so far are still waited for
+			ok = false
+			break
+		}
+
+		message := messages[i]
+
+		if message.BatchID != "" {
+			end := i + 1
+			for end < len(messages) && messages[end].BatchID == message.BatchID {
+				end++
+			}
+
+			published := r.publishBatch(messages[i:end])
+			i = end - 1
+
+			if !published {
+				ok = false
+				break
+			}
+			continue
+		}
+
+		key := outboxKey{message.Topic, message.Key}
+
+		if earlier, found := inFlight[key]; found {
+			if earlier.err == nil {
+				earlier.err = earlier.delivery.Wait(context.Background())
+			}
+			if earlier.err != nil {
+				ok = false
+				continue
+			}
+		}
+
+		ctx, span := r.startSpan("relay outbox message", message,
+			attribute.String("app.outbox.message_id", message.ID),
+			attribute.Int("app.outbox.attempts", message.Attempts),
+		)
+
+		delivery, err := r.producer.PublishAsync(ctx, message.Topic, newKafkaMessage(message))
+
+		publish := &outboxPublish{ctx, span, message, delivery, err}
+		publishes = append(publishes, publish)
+		inFlight[key] = publish
+
+		if err != nil {
+			ok = false
+			break
+		}
+	}
+
+	for _, publish := range publishes {
+		if publish.err == nil {
+			publish.err = publish.delivery.Wait(context.Background())
+		}
+
+		if !r.finish(publish.ctx, publish.span, publish.err, publish.message) {
+			ok = false
+		}
+	}
+
+	return ok
+}
+
+// publishBatch publishes the messages of one batch in a Kafka transaction, they are all marked sent or all failed
+func (r *OutboxRelay) publishBatch(batch []*models.OutboxMessage) bool {
+	first := batch[0]
+
+	ctx, span := r.startSpan("relay outbox batch", first,
+		attribute.String("app.outbox.batch_id", first.BatchID),
+		attribute.Int("messaging.batch.message_count", len(batch)),
+		attribute.Int("app.outbox.attempts", first.Attempts),
+	)
+
+	messages := make([]kafka.Message, 0, len(batch))
+	var err error
+
+	for _, message := range batch {
+		if message.Topic != first.Topic {
+			err = fmt.Errorf("outbox batch %s spans the topics %s and %s", first.BatchID, first.Topic, message.Topic)
+			break
+		}
+
+		messages = append(messages, newKafkaMessage(message))
+	}
+
+	if err == nil {
+		err = r.producer.PublishBatch(ctx, first.Topic, messages)
+	}
+
+	return r.finish(ctx, span, err, batch...)
+}
+
+// startSpan starts a new trace linked to the request that wrote message
+func (r *OutboxRelay) startSpan(name string, message *models.OutboxMessage, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	origin := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier(message.TraceContext))
+
+	return r.tracer.Start(context.Background(), name,
+		trace.WithLinks(trace.LinkFromContext(origin)),
+		trace.WithAttributes(append(attributes, semconv.MessagingDestinationName(message.Topic))...),
+	)
+}
+
+// finish records the outcome of a publish of messages in the outbox and ends its span
+func (r *OutboxRelay) finish(ctx context.Context, span trace.Span, err error, messages ...*models.OutboxMessage) bool {
+	defer span.End()
+
+	topic := semconv.MessagingDestinationName(messages[0].Topic)
+	count := int64(len(messages))
+
+	ids := make([]string, len(messages))
+	for i, message := range messages {
+		ids[i] = message.ID
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		r.failed.Add(ctx, count, metric.WithAttributes(topic))
+
+		attempts := messages[0].Attempts + 1
+		nextAttemptAt := time.Now().UTC().Add(r.backoff(attempts))
+		if markErr := r.repository.MarkFailed(ctx, err.Error(), nextAttemptAt, ids...); markErr != nil {
+			r.logger.WithContext(ctx).WithError(markErr).Error("Failed to record a failed outbox publish")
+		}
+
+		r.logger.WithContext(ctx).WithError(err).WithFields(logrus.Fields{
+			"message_ids":     ids,
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt.Format(time.RFC3339),
+		}).Warn("Failed to publish outbox message, will retry")
+
+		return false
+	}
+
+	r.published.Add(ctx, count, metric.WithAttributes(topic))
+
+	// A failure here publishes the messages again later, consumers can drop the duplicates by their event IDs
+	if err := r.repository.MarkSent(ctx, time.Now().UTC(), ids...); err != nil {
+		span.RecordError(err)
+		r.logger.WithContext(ctx).WithError(err).Error("Failed to mark outbox message sent")
+		return false
+	}
+
+	return true
+}
+
+func (r *OutboxRelay) stopping() bool {
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// backoff doubles the poll interval with every attempt, up to outboxMaxBackoff
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	delay := r.interval
+	for i := 1; i < attempts && delay < outboxMaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, outboxMaxBackoff)
+}
+
+func newKafkaMessage(message *models.OutboxMessage) kafka.Message {
+	return kafka.Message{
+		ID:           message.ID,
+		Key:          message.Key,
+		Value:        message.Value,
+		Type:         message.Type,
+		ContentType:  message.ContentType,
+		Time:         message.CreatedAt,
+		TraceContext: message.TraceContext,
+	}
+}
+
+// newOutboxMessage captures the trace context of ctx, so the relay can link its publish to the current request
+func newOutboxMessage(ctx context.Context, id, topic string, message kafka.Message) *models.OutboxMessage {
+	traceContext := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, traceContext)
+
+	now := time.Now().UTC()
+
+	return &models.OutboxMessage{
+		ID:            id,
+		Topic:         topic,
+		Key:           message.Key,
+		Value:         message.Value,
+		Type:          message.Type,
+		ContentType:   message.ContentType,
+		TraceContext:  traceContext,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/models"
+	"github.com/demo/rolldice/internal/rolldice/repositories"
+	"github.com/demo/rolldice/pkg/database"
+	"github.com/demo/rolldice/pkg/messaging/kafka"
+	"github.com/sirupsen/logrus"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+var errBrokerDown = errors.New("broker down")
+
+// fakeProducer fails every publish of a failing message and records the IDs of the others
+type fakeProducer struct {
+	mu        sync.Mutex
+	failing   map[string]bool
+	published []string
+	batches   [][]string
+}
+
+func (p *fakeProducer) PublishAsync(_ context.Context, topic string, message kafka.Message) (*kafka.Delivery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failing[message.ID] {
+		return kafka.CompletedDelivery(topic, errBrokerDown), nil
+	}
+
+	p.published = append(p.published, message.ID)
+
+	return kafka.CompletedDelivery(topic, nil), nil
+}
+
+func (p *fakeProducer) PublishBatch(_ context.Context, _ string, messages []kafka.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ids := []string{}
+	for _, message := range messages {
+		if p.failing[message.ID] {
+			return errBrokerDown
+		}
+		ids = append(ids, message.ID)
+	}
+
+	p.published = append(p.published, ids...)
+	p.batches = append(p.batches, ids)
+
+	return nil
+}
+
+func (p *fakeProducer) Transactional() bool {
+	return true
+}
+
+func TestOutboxRelayPublishesBatchesInOneTransaction(t *testing.T) {
+	producer := &fakeProducer{failing: map[string]bool{"m2": true}}
+	relay, rollRepository, outboxRepository := newOutboxRelay(t, producer)
+	ctx := context.Background()
+
+	rolls := []*models.Roll{{ID: "r1"}, {ID: "r2"}}
+	if err := rollRepository.CreateMany(ctx, rolls, outboxMessage(ctx, "m1", "r1", "batch"), outboxMessage(ctx, "m2", "r2", "batch")); err != nil {
+		t.Fatal(err)
+	}
+
+	relay.relay()
+
+	if len(producer.published) != 0 {
+		t.Fatalf("published %v while a message of the batch fails, want nothing", producer.published)
+	}
+
+	messages, err := outboxRepository.Pending(ctx, time.Now().UTC(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, message := range messages {
+		if message.Attempts != 1 {
+			t.Errorf("%s: %d attempts, want 1 for every message of the failed batch", message.ID, message.Attempts)
+		}
+	}
+
+	producer.failing = nil
+	relay.relay()
+
+	if want := [][]string{{"m1", "m2"}}; !reflect.DeepEqual(producer.batches, want) {
+		t.Errorf("batches = %v, want %v", producer.batches, want)
+	}
+
+	if count, err := outboxRepository.CountPending(ctx); err != nil || count != 0 {
+		t.Errorf("%d messages pending (%v), want 0", count, err)
+	}
+}
+
+func TestOutboxRelayKeepsTheOrderOfAKey(t *testing.T) {
+	producer := &fakeProducer{failing: map[string]bool{"m1": true}}
+	relay, rollRepository, outboxRepository := newOutboxRelay(t, producer)
+	ctx := context.Background()
+
+	if err := rollRepository.Create(ctx, &models.Roll{ID: "r1"}, outboxMessage(ctx, "m1", "session", ""), outboxMessage(ctx, "m2", "session", "")); err != nil {
+		t.Fatal(err)
+	}
+	if err := rollRepository.Create(ctx, &models.Roll{ID: "r2"}, outboxMessage(ctx, "m3", "other", "")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The message of another key is not held back
+	relay.relay()
+	relay.relay()
+
+	if want := []string{"m3"}; !reflect.DeepEqual(producer.published, want) {
+		t.Fatalf("published %v while m1 fails, want %v", producer.published, want)
+	}
+
+	if count, err := outboxRepository.CountPending(ctx); err != nil || count != 2 {
+		t.Errorf("%d messages pending (%v), want m1 and m2", count, err)
+	}
+
+	producer.failing = nil
+	relay.relay()
+
+	if want := []string{"m3", "m1", "m2"}; !reflect.DeepEqual(producer.published, want) {
+		t.Errorf("published %v, want %v", producer.published, want)
+	}
+}
+
+func TestOutboxRelayHoldsBackAKeyInFlight(t *testing.T) {
+	producer := &fakeProducer{failing: map[string]bool{"m1": true}}
+	relay, _, _ := newOutboxRelay(t, producer)
+	ctx := context.Background()
+
+	// The outbox returns one message per key, a page with two is only held back by the relay
+	messages := []*models.OutboxMessage{outboxMessage(ctx, "m1", "session", ""), outboxMessage(ctx, "m2", "session", ""), outboxMessage(ctx, "m3", "other", "")}
+
+	if relay.publish(messages) {
+		t.Error("publish succeeded while m1 failed")
+	}
+
+	if want := []string{"m3"}; !reflect.DeepEqual(producer.published, want) {
+		t.Errorf("published %v, want %v", producer.published, want)
+	}
+}
+
+// newOutboxRelay retries failed messages right away, its interval is the first backoff
+func newOutboxRelay(t *testing.T, producer OutboxProducer) (*OutboxRelay, *repositories.SQLiteRollRepository, *repositories.SQLiteOutboxRepository) {
+	t.Helper()
+
+	tracer := noop.NewTracerProvider().Tracer("")
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "rolldice.db"), tracer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	rollRepository, err := repositories.NewSQLiteRollRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outboxRepository, err := repositories.NewSQLiteOutboxRepository(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	relay, err := NewOutboxRelay(tracer, logger, metricnoop.NewMeterProvider().Meter(""), outboxRepository, producer, time.Nanosecond, 10, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return relay, rollRepository, outboxRepository
+}
+
+// outboxMessage is a roll event keyed by key, created after the messages before it
+func outboxMessage(ctx context.Context, id, key, batchID string) *models.OutboxMessage {
+	time.Sleep(time.Microsecond)
+
+	message := newOutboxMessage(ctx, id, RollTopic, kafka.Message{Key: key})
+	message.BatchID = batchID
+
+	return message
+}
@@ -2,76 +2,322 @@ package services
 
 import (
 	"context"
-	"encoding/json"
-	"math/rand"
-	"strconv"
-	"sync/atomic"
+	"errors"
 	"time"
 
+	"github.com/demo/rolldice/internal/events"
+	"github.com/demo/rolldice/internal/rolldice/auth"
+	"github.com/demo/rolldice/internal/rolldice/dice"
+	"github.com/demo/rolldice/internal/rolldice/models"
+	"github.com/demo/rolldice/internal/rolldice/random"
+	"github.com/demo/rolldice/internal/rolldice/repositories"
+	exception "github.com/demo/rolldice/pkg/exceptions"
+	"github.com/demo/rolldice/pkg/idgen"
 	"github.com/demo/rolldice/pkg/messaging/kafka"
+	"github.com/demo/rolldice/pkg/messaging/serde"
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 )
 
+const (
+	DefaultExpression = "1d6"
+	RollTopic         = "poc.rolldice"
+)
+
+var (
+	ErrNonceWithoutClientSeed = exception.New("roll.nonce_without_client_seed", exception.CategoryValidation, "nonce requires a client_seed")
+	ErrInvalidExpression      = exception.New("roll.invalid_expression", exception.CategoryValidation, "invalid dice expression")
+)
+
 type RollDiceService struct {
-	tracer   trace.Tracer
-	logger   *logrus.Logger
-	producer *kafka.KafkaProducer
+	tracer      trace.Tracer
+	logger      *logrus.Logger
+	source      random.RandomSource
+	repository  repositories.RollRepository
+	idGenerator idgen.Generator
+	fairness    *FairnessService
+	stream      *StreamService
+	relay       *OutboxRelay
+	serializer  *serde.Serializer
 }
 
-type RollEvent struct {
-	RollID    string `json:"roll_id"`
-	Result    int    `json:"result"`
-	Timestamp string `json:"timestamp"`
+type RollRequest struct {
+	Expression string
+	RollerID   string
+	SessionID  string
+	// ClientSeed makes the roll provably fair, Nonce defaults to the next nonce of the active server seed
+	ClientSeed string
+	Nonce      *int64
+	// sessionPlayer keeps RollerID, the session player the roll counts for
+	sessionPlayer bool
 }
 
-func NewRollDiceService(tracer trace.Tracer, logger *logrus.Logger, producer *kafka.KafkaProducer) *RollDiceService {
+func NewRollDiceService(tracer trace.Tracer, logger *logrus.Logger, source random.RandomSource, repository repositories.RollRepository, idGenerator idgen.Generator, fairness *FairnessService, stream *StreamService, relay *OutboxRelay, serializer *serde.Serializer) *RollDiceService {
 	return &RollDiceService{
 		tracer,
 		logger,
-		producer,
+		source,
+		repository,
+		idGenerator,
+		fairness,
+		stream,
+		relay,
+		serializer,
 	}
 }
 
-func (s *RollDiceService) Dice(ctx context.Context) (int, error) {
+func (s *RollDiceService) Dice(ctx context.Context, request RollRequest) (*models.Roll, error) {
 	ctx, span := s.tracer.Start(ctx, "Rolling")
 
 	defer span.End()
 
-	randSrc := rand.NewSource(time.Now().UnixNano())
-	rnd := rand.New(randSrc)
+	roll, err := s.roll(ctx, span, request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	outbox, err := s.rollOutbox(ctx, roll)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The event is stored with the roll and published by the relay, a Kafka outage delays it instead of failing the roll
+	if err := s.repository.Create(ctx, roll, outbox); err != nil {
+		return nil, err
+	}
+
+	s.rolled(roll)
+
+	s.logger.WithContext(ctx).Infof("Roll result of %s = %d", roll.Expression, roll.Result)
+
+	return roll, nil
+}
+
+// DiceBatch rolls every request and stores the rolls with their events in one transaction, the relay publishes
+// the events in one Kafka transaction
+func (s *RollDiceService) DiceBatch(ctx context.Context, requests []RollRequest) ([]*models.Roll, error) {
+	ctx, span := s.tracer.Start(ctx, "Rolling batch")
+
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("app.roll.batch_size", len(requests)))
+
+	if !s.relay.Transactional() {
+		return nil, kafka.ErrTransactionsDisabled
+	}
+
+	batchID := s.idGenerator.NewID()
+	rolls := make([]*models.Roll, 0, len(requests))
+	outbox := make([]*models.OutboxMessage, 0, len(requests))
+
+	for _, request := range requests {
+		roll, err := s.rollChild(ctx, request)
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+
+		message, err := s.rollOutbox(ctx, roll)
+
+		if err != nil {
+			return nil, err
+		}
+
+		message.BatchID = batchID
+
+		rolls = append(rolls, roll)
+		outbox = append(outbox, message)
+	}
+
+	if err := s.repository.CreateMany(ctx, rolls, outbox...); err != nil {
+		return nil, err
+	}
 
-	diceRoll := rnd.Intn(6) + 1
+	s.rolled(rolls...)
 
-	rollId := strconv.Itoa(generateRollID())
+	s.logger.WithContext(ctx).Infof("Rolled a batch of %d", len(rolls))
 
-	rollEvent := RollEvent{
-		RollID:    rollId,
-		Result:    diceRoll,
-		Timestamp: time.Now().Format(time.RFC3339),
+	return rolls, nil
+}
+
+func (s *RollDiceService) rollChild(ctx context.Context, request RollRequest) (*models.Roll, error) {
+	ctx, span := s.tracer.Start(ctx, "Rolling")
+
+	defer span.End()
+
+	return s.roll(ctx, span, request)
+}
+
+// roll parses and evaluates the expression and records the outcome on span
+func (s *RollDiceService) roll(ctx context.Context, span trace.Span, request RollRequest) (*models.Roll, error) {
+	if request.Nonce != nil && request.ClientSeed == "" {
+		return nil, ErrNonceWithoutClientSeed
 	}
 
-	value, _ := json.Marshal(rollEvent)
+	expression := request.Expression
+	if expression == "" {
+		expression = DefaultExpression
+	}
 
-	err := s.producer.Publish(ctx, "poc.rolldice", string(value), rollId)
+	expr, err := s.parse(ctx, expression)
 
 	if err != nil {
-		return 0, err
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
 	}
 
-	s.logger.WithContext(ctx).Infof("Roll result = %d", diceRoll)
+	var source random.RandomSource = s.source
+	var fairness *models.Fairness
+
+	if request.ClientSeed != "" {
+		hmacSource, commitment, release, err := s.fairness.Source(ctx, request.ClientSeed, request.Nonce)
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer release()
+
+		source = hmacSource
+		fairness = commitment
+
+		span.SetAttributes(
+			attribute.String("app.fairness.server_seed_hash", fairness.ServerSeedHash),
+			attribute.Int64("app.fairness.nonce", fairness.Nonce),
+		)
+	}
+
+	span.SetAttributes(attribute.String("app.roll.random_source", source.Name()))
+
+	result, err := dice.NewEvaluator(s.tracer, source).Evaluate(ctx, expr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	roll := &models.Roll{
+		ID:         s.idGenerator.NewID(),
+		Expression: result.Expression,
+		Terms:      result.Terms,
+		Result:     result.Total,
+		RollerID:   rollerID(ctx, request),
+		SessionID:  request.SessionID,
+		Fairness:   fairness,
+		CreatedAt:  time.Now().UTC(),
+	}
 
 	span.SetAttributes(
-		attribute.Int("app.roll.result", diceRoll),
+		attribute.String("app.roll.id", roll.ID),
+		attribute.String("app.roll.expression", roll.Expression),
+		attribute.IntSlice("app.roll.values", result.Values()),
+		attribute.Int("app.roll.result", roll.Result),
 	)
 
-	return diceRoll, nil
+	return roll, nil
 }
 
-// Counter to generate dynamic RollID
-var rollIDCounter int32
+// rollerID attributes the roll to the authenticated caller, the requested roller ID is only kept for anonymous rolls
+// and session rolls, whose player is bound to the caller instead
+func rollerID(ctx context.Context, request RollRequest) string {
+	if request.sessionPlayer {
+		return request.RollerID
+	}
+	if subject := auth.Subject(ctx); subject != "" {
+		return subject
+	}
+	return request.RollerID
+}
+
+// rollOutbox is the RollEvent of roll, keyed by roll ID so the events of a roll stay ordered.
+// It must be stored in the transaction of the roll
+func (s *RollDiceService) rollOutbox(ctx context.Context, roll *models.Roll) (*models.OutboxMessage, error) {
+	value, err := s.serializer.Serialize(newRollEvent(roll))
+	if err != nil {
+		return nil, err
+	}
+
+	return newOutboxMessage(ctx, s.idGenerator.NewID(), RollTopic, kafka.Message{
+		Key:         roll.ID,
+		Value:       string(value),
+		Type:        events.RollEventType,
+		ContentType: s.serializer.ContentType(),
+		Time:        roll.CreatedAt,
+	}), nil
+}
+
+// rolled announces rolls stored with their events
+func (s *RollDiceService) rolled(rolls ...*models.Roll) {
+	s.relay.Notify()
+
+	s.stream.Broadcast(rolls...)
+}
 
-func generateRollID() int {
-	return int(atomic.AddInt32(&rollIDCounter, 1))
+func newRollEvent(roll *models.Roll) *events.RollEvent {
+	event := &events.RollEvent{
+		RollID:     roll.ID,
+		Expression: roll.Expression,
+		Terms:      make([]events.RollTerm, 0, len(roll.Terms)),
+		Result:     roll.Result,
+		RollerID:   roll.RollerID,
+		SessionID:  roll.SessionID,
+		Timestamp:  roll.CreatedAt.Format(time.RFC3339),
+	}
+
+	for _, term := range roll.Terms {
+		rollTerm := events.RollTerm{
+			Notation: term.Notation,
+			Sign:     term.Sign,
+			Sides:    term.Sides,
+			Subtotal: term.Subtotal,
+		}
+		for _, die := range term.Dice {
+			rollTerm.Dice = append(rollTerm.Dice, events.Die(die))
+		}
+		event.Terms = append(event.Terms, rollTerm)
+	}
+
+	if roll.Fairness != nil {
+		fairness := events.Fairness(*roll.Fairness)
+		event.Fairness = &fairness
+	}
+
+	return event
+}
+
+func (s *RollDiceService) GetRoll(ctx context.Context, id string) (*models.Roll, error) {
+	return s.repository.FindByID(ctx, id)
+}
+
+func (s *RollDiceService) ListRolls(ctx context.Context, filter models.RollFilter) (*models.RollPage, error) {
+	return s.repository.List(ctx, filter)
+}
+
+func (s *RollDiceService) parse(ctx context.Context, expression string) (*dice.Expression, error) {
+	_, span := s.tracer.Start(ctx, "dice.parse")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("app.dice.source", expression))
+
+	expr, err := dice.Parse(expression)
+	if err != nil {
+		return nil, invalidExpression(err)
+	}
+
+	return expr, nil
+}
+
+// invalidExpression keeps the position of a syntax error in the public message
+func invalidExpression(err error) error {
+	var syntaxErr *dice.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return ErrInvalidExpression.WithMessage(syntaxErr.Error())
+	}
+	return err
 }
@@ -0,0 +1,411 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/demo/rolldice/internal/events"
+	"github.com/demo/rolldice/internal/rolldice/auth"
+	"github.com/demo/rolldice/internal/rolldice/dice"
+	"github.com/demo/rolldice/internal/rolldice/models"
+	"github.com/demo/rolldice/internal/rolldice/repositories"
+	exception "github.com/demo/rolldice/pkg/exceptions"
+	"github.com/demo/rolldice/pkg/idgen"
+	"github.com/demo/rolldice/pkg/messaging/kafka"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	SessionTopic = "poc.rolldice.session"
+
+	MaxSessionPlayers = 20
+	MaxSessionRounds  = 100
+)
+
+type SessionEventType string
+
+const (
+	SessionCreated  SessionEventType = "session.created"
+	SessionJoined   SessionEventType = "session.joined"
+	SessionRolled   SessionEventType = "session.rolled"
+	SessionFinished SessionEventType = "session.finished"
+)
+
+var (
+	ErrSessionStarted  = exception.New("session.started", exception.CategoryConflict, "session already started")
+	ErrSessionFinished = exception.New("session.finished", exception.CategoryConflict, "session is finished")
+	ErrSessionFull     = exception.New("session.full", exception.CategoryConflict, "session is full")
+	ErrSessionEmpty    = exception.New("session.empty", exception.CategoryConflict, "session has no players")
+	ErrPlayerNotFound  = exception.New("session.player_not_found", exception.CategoryNotFound, "player not found in session")
+	ErrNotPlayersTurn  = exception.New("session.not_players_turn", exception.CategoryConflict, "it is not this player's turn")
+	ErrNotPlayer       = exception.New("session.not_player", exception.CategoryForbidden, "the player belongs to another caller")
+)
+
+type SessionRequest struct {
+	Name       string
+	Expression string
+	MaxRounds  int
+}
+
+type PlayerScore struct {
+	PlayerID string `json:"player_id"`
+	Name     string `json:"name"`
+	Score    int    `json:"score"`
+}
+
+// SessionEvent is published with the session ID as key, so the events of one session stay ordered
+type SessionEvent struct {
+	Type       SessionEventType `json:"type"`
+	SessionID  string           `json:"session_id"`
+	Name       string           `json:"name"`
+	Expression string           `json:"expression"`
+	Status     string           `json:"status"`
+	Round      int              `json:"round"`
+	PlayerID   string           `json:"player_id,omitempty"`
+	PlayerName string           `json:"player_name,omitempty"`
+	RollID     string           `json:"roll_id,omitempty"`
+	Result     *int             `json:"result,omitempty"`
+	NextPlayer *PlayerScore     `json:"next_player,omitempty"`
+	Scores     []PlayerScore    `json:"scores"`
+	Winners    []PlayerScore    `json:"winners,omitempty"`
+	Timestamp  string           `json:"timestamp"`
+}
+
+type SessionService struct {
+	tracer          trace.Tracer
+	logger          *logrus.Logger
+	relay           *OutboxRelay
+	repository      repositories.SessionRepository
+	idGenerator     idgen.Generator
+	rolldiceService *RollDiceService
+	// locks serializes changes to a session within this instance, the version check covers the others.
+	// An entry only lives while a change to its session holds or waits for it
+	locksMu sync.Mutex
+	locks   map[string]*sessionLock
+}
+
+type sessionLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func NewSessionService(tracer trace.Tracer, logger *logrus.Logger, relay *OutboxRelay, repository repositories.SessionRepository, idGenerator idgen.Generator, rolldiceService *RollDiceService) *SessionService {
+	return &SessionService{
+		tracer:          tracer,
+		logger:          logger,
+		relay:           relay,
+		repository:      repository,
+		idGenerator:     idGenerator,
+		rolldiceService: rolldiceService,
+		locks:           map[string]*sessionLock{},
+	}
+}
+
+func (s *SessionService) Create(ctx context.Context, request SessionRequest) (*models.Session, error) {
+	ctx, span := s.tracer.Start(ctx, "Create session")
+	defer span.End()
+
+	expression := request.Expression
+	if expression == "" {
+		expression = DefaultExpression
+	}
+
+	expr, err := dice.Parse(expression)
+	if err != nil {
+		return nil, invalidExpression(err)
+	}
+
+	now := time.Now().UTC()
+	session := &models.Session{
+		ID:         s.idGenerator.NewID(),
+		Name:       request.Name,
+		Expression: expr.String(),
+		Status:     models.SessionWaiting,
+		MaxRounds:  request.MaxRounds,
+		Players:    []models.SessionPlayer{},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	setSessionAttributes(span, session)
+
+	if err := s.repository.Create(ctx, session, s.outbox(ctx, newSessionEvent(SessionCreated, session))); err != nil {
+		return nil, err
+	}
+
+	s.relay.Notify()
+
+	s.logger.WithContext(ctx).Infof("Session %s created", session.ID)
+
+	return session, nil
+}
+
+func (s *SessionService) Get(ctx context.Context, id string) (*models.Session, error) {
+	return s.repository.FindByID(ctx, id)
+}
+
+// Join adds a player to a session that has not started rolling yet
+func (s *SessionService) Join(ctx context.Context, sessionID string, name string) (*models.Session, *models.SessionPlayer, error) {
+	ctx, span := s.tracer.Start(ctx, "Join session")
+	defer span.End()
+
+	unlock := s.lock(sessionID)
+	defer unlock()
+
+	session, err := s.repository.FindByID(ctx, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	setSessionAttributes(span, session)
+
+	switch {
+	case session.Status == models.SessionFinished:
+		return nil, nil, ErrSessionFinished
+	case session.Status != models.SessionWaiting:
+		return nil, nil, ErrSessionStarted
+	case len(session.Players) >= MaxSessionPlayers:
+		return nil, nil, ErrSessionFull
+	}
+
+	player := &models.SessionPlayer{
+		ID:       s.idGenerator.NewID(),
+		Name:     name,
+		Subject:  auth.Subject(ctx),
+		JoinedAt: time.Now().UTC(),
+	}
+
+	span.SetAttributes(attribute.String("app.session.player_id", player.ID))
+
+	// The event describes the session with the player, as AddPlayer leaves it
+	joined := *session
+	joined.Players = append(append([]models.SessionPlayer{}, session.Players...), *player)
+	joined.UpdatedAt = player.JoinedAt
+
+	event := newSessionEvent(SessionJoined, &joined)
+	event.PlayerID = player.ID
+	event.PlayerName = player.Name
+
+	if err := s.repository.AddPlayer(ctx, session, player, s.outbox(ctx, event)); err != nil {
+		return nil, nil, err
+	}
+
+	s.relay.Notify()
+
+	s.logger.WithContext(ctx).Infof("Player %s joined session %s", player.Name, session.ID)
+
+	return session, player, nil
+}
+
+// Roll rolls the session expression for the player whose turn it is and adds the result to their score,
+// the session finishes by itself once every player rolled MaxRounds times
+func (s *SessionService) Roll(ctx context.Context, sessionID string, playerID string) (*models.Session, *models.Roll, error) {
+	ctx, span := s.tracer.Start(ctx, "Session roll")
+	defer span.End()
+
+	unlock := s.lock(sessionID)
+	defer unlock()
+
+	session, err := s.repository.FindByID(ctx, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	setSessionAttributes(span, session)
+	span.SetAttributes(attribute.String("app.session.player_id", playerID))
+
+	if session.Status == models.SessionFinished {
+		return nil, nil, ErrSessionFinished
+	}
+	if len(session.Players) == 0 {
+		return nil, nil, ErrSessionEmpty
+	}
+
+	player := session.Player(playerID)
+	if player == nil {
+		return nil, nil, ErrPlayerNotFound
+	}
+	// Only the caller who joined as an authenticated player can roll for them
+	if player.Subject != "" && player.Subject != auth.Subject(ctx) {
+		return nil, nil, ErrNotPlayer
+	}
+	if session.CurrentPlayer().ID != player.ID {
+		return nil, nil, ErrNotPlayersTurn
+	}
+
+	round := session.Round()
+
+	roll, err := s.rolldiceService.rollChild(ctx, RollRequest{
+		Expression:    session.Expression,
+		RollerID:      player.ID,
+		SessionID:     session.ID,
+		sessionPlayer: true,
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, nil, err
+	}
+
+	rollOutbox, err := s.rolldiceService.rollOutbox(ctx, roll)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	player.Score += roll.Result
+	player.Rolls++
+	session.Turn++
+	session.Status = models.SessionPlaying
+	session.UpdatedAt = time.Now().UTC()
+
+	finished := session.MaxRounds > 0 && session.Turn >= session.MaxRounds*len(session.Players)
+	if finished {
+		finishedAt := session.UpdatedAt
+		session.Status = models.SessionFinished
+		session.FinishedAt = &finishedAt
+	}
+
+	event := newSessionEvent(SessionRolled, session)
+	event.Round = round
+	event.PlayerID = player.ID
+	event.PlayerName = player.Name
+	event.RollID = roll.ID
+	event.Result = &roll.Result
+
+	outbox := []*models.OutboxMessage{rollOutbox, s.outbox(ctx, event)}
+	if finished {
+		outbox = append(outbox, s.outbox(ctx, newSessionEvent(SessionFinished, session)))
+	}
+
+	// The roll, the score it adds and every event are stored together, a conflict discards all of them
+	if err := s.repository.SaveRoll(ctx, session, roll, outbox...); err != nil {
+		return nil, nil, err
+	}
+
+	s.rolldiceService.rolled(roll)
+
+	span.SetAttributes(
+		attribute.String("app.roll.id", roll.ID),
+		attribute.Int("app.session.round", round),
+	)
+
+	s.logger.WithContext(ctx).Infof("Player %s rolled %d in session %s", player.Name, roll.Result, session.ID)
+
+	return session, roll, nil
+}
+
+// Finish closes the session, the players sharing the highest score win
+func (s *SessionService) Finish(ctx context.Context, sessionID string) (*models.Session, error) {
+	ctx, span := s.tracer.Start(ctx, "Finish session")
+	defer span.End()
+
+	unlock := s.lock(sessionID)
+	defer unlock()
+
+	session, err := s.repository.FindByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	setSessionAttributes(span, session)
+
+	if session.Status == models.SessionFinished {
+		return nil, ErrSessionFinished
+	}
+
+	now := time.Now().UTC()
+	session.Status = models.SessionFinished
+	session.UpdatedAt = now
+	session.FinishedAt = &now
+
+	if err := s.repository.Save(ctx, session, s.outbox(ctx, newSessionEvent(SessionFinished, session))); err != nil {
+		return nil, err
+	}
+
+	s.relay.Notify()
+
+	s.logger.WithContext(ctx).Infof("Session %s finished", session.ID)
+
+	return session, nil
+}
+
+// lock counts the holder and waiters of a session lock, the last one to leave removes it so unknown or finished
+// session IDs do not accumulate
+func (s *SessionService) lock(sessionID string) func() {
+	s.locksMu.Lock()
+	l, ok := s.locks[sessionID]
+	if !ok {
+		l = &sessionLock{}
+		s.locks[sessionID] = l
+	}
+	l.refs++
+	s.locksMu.Unlock()
+
+	l.mu.Lock()
+
+	return func() {
+		l.mu.Unlock()
+
+		s.locksMu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(s.locks, sessionID)
+		}
+		s.locksMu.Unlock()
+	}
+}
+
+// outbox is the message of event, stored in the transaction of the change it announces
+func (s *SessionService) outbox(ctx context.Context, event SessionEvent) *models.OutboxMessage {
+	value, _ := json.Marshal(event)
+
+	return newOutboxMessage(ctx, s.idGenerator.NewID(), SessionTopic, kafka.Message{
+		Key:   event.SessionID,
+		Value: string(value),
+		Type:  events.EventTypePrefix + string(event.Type),
+	})
+}
+
+func newSessionEvent(eventType SessionEventType, session *models.Session) SessionEvent {
+	event := SessionEvent{
+		Type:       eventType,
+		SessionID:  session.ID,
+		Name:       session.Name,
+		Expression: session.Expression,
+		Status:     string(session.Status),
+		Round:      session.Round(),
+		Scores:     playerScores(session.Players),
+		Timestamp:  session.UpdatedAt.Format(time.RFC3339),
+	}
+
+	if player := session.CurrentPlayer(); player != nil {
+		event.NextPlayer = &PlayerScore{player.ID, player.Name, player.Score}
+	}
+
+	if eventType == SessionFinished {
+		event.Winners = playerScores(session.Leaders())
+	}
+
+	return event
+}
+
+func playerScores(players []models.SessionPlayer) []PlayerScore {
+	scores := make([]PlayerScore, len(players))
+	for i, player := range players {
+		scores[i] = PlayerScore{player.ID, player.Name, player.Score}
+	}
+	return scores
+}
+
+func setSessionAttributes(span trace.Span, session *models.Session) {
+	span.SetAttributes(
+		attribute.String("app.session.id", session.ID),
+		attribute.String("app.session.status", string(session.Status)),
+		attribute.Int("app.session.players", len(session.Players)),
+	)
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/dice"
+	"github.com/demo/rolldice/internal/rolldice/models"
+	"github.com/demo/rolldice/internal/rolldice/repositories"
+	"github.com/demo/rolldice/internal/rolldice/stats"
+	exception "github.com/demo/rolldice/pkg/exceptions"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const DefaultStatsSides = 6
+
+var ErrInvalidStatsWindow = exception.New("stats.invalid_window", exception.CategoryValidation, "stats window must end after it starts")
+
+type StatsRequest struct {
+	Sides int
+	From  time.Time
+	To    time.Time
+}
+
+type RollStats struct {
+	From  time.Time `json:"from"`
+	To    time.Time `json:"to"`
+	Rolls int       `json:"rolls"`
+	stats.Summary
+}
+
+type StatsService struct {
+	tracer        trace.Tracer
+	logger        *logrus.Logger
+	repository    repositories.RollRepository
+	metricsWindow time.Duration
+}
+
+// NewStatsService exports the statistics of the last metricsWindow of d6 rolls as observable gauges on meter
+func NewStatsService(tracer trace.Tracer, logger *logrus.Logger, meter metric.Meter, repository repositories.RollRepository, metricsWindow time.Duration) (*StatsService, error) {
+	s := &StatsService{
+		tracer:        tracer,
+		logger:        logger,
+		repository:    repository,
+		metricsWindow: metricsWindow,
+	}
+
+	if err := s.registerMetrics(meter); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *StatsService) Stats(ctx context.Context, request StatsRequest) (*RollStats, error) {
+	ctx, span := s.tracer.Start(ctx, "Computing roll stats")
+	defer span.End()
+
+	if !request.To.After(request.From) {
+		return nil, ErrInvalidStatsWindow
+	}
+
+	accumulator := stats.NewAccumulator(request.Sides)
+	rolls := 0
+
+	err := s.repository.Each(ctx, request.From, request.To, func(roll *models.Roll) error {
+		counted := false
+		for _, term := range roll.Terms {
+			if termSides(term) != request.Sides {
+				continue
+			}
+			counted = true
+			for _, die := range term.Dice {
+				// Rerolled faces were thrown too, a fair die must be fair on those as well
+				for _, value := range die.Rerolled {
+					accumulator.Add(value)
+				}
+				accumulator.Add(die.Value)
+			}
+		}
+		if counted {
+			rolls++
+		}
+		return nil
+	})
+
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	result := &RollStats{
+		From:    request.From,
+		To:      request.To,
+		Rolls:   rolls,
+		Summary: accumulator.Summary(),
+	}
+
+	span.SetAttributes(
+		attribute.Int("app.stats.sides", result.Sides),
+		attribute.Int("app.stats.dice", result.Dice),
+		attribute.Float64("app.stats.chi_square", result.ChiSquare.Statistic),
+		attribute.Float64("app.stats.p_value", result.ChiSquare.PValue),
+	)
+
+	return result, nil
+}
+
+func (s *StatsService) registerMetrics(meter metric.Meter) error {
+	faceCount, err := meter.Int64ObservableGauge("app.dice.face.count", metric.WithDescription("Die faces rolled in the stats window"))
+	if err != nil {
+		return err
+	}
+	mean, err := meter.Float64ObservableGauge("app.dice.mean", metric.WithDescription("Mean die value in the stats window"))
+	if err != nil {
+		return err
+	}
+	variance, err := meter.Float64ObservableGauge("app.dice.variance", metric.WithDescription("Sample variance of die values in the stats window"))
+	if err != nil {
+		return err
+	}
+	longestStreak, err := meter.Int64ObservableGauge("app.dice.streak.longest", metric.WithDescription("Longest run of the same face in the stats window"))
+	if err != nil {
+		return err
+	}
+	chiSquare, err := meter.Float64ObservableGauge("app.dice.chi_square", metric.WithDescription("Chi-square statistic against a fair die in the stats window"))
+	if err != nil {
+		return err
+	}
+	pValue, err := meter.Float64ObservableGauge("app.dice.chi_square.p_value", metric.WithDescription("Chi-square goodness-of-fit p-value in the stats window"))
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, observer metric.Observer) error {
+		now := time.Now().UTC()
+		result, err := s.Stats(ctx, StatsRequest{Sides: DefaultStatsSides, From: now.Add(-s.metricsWindow), To: now})
+		if err != nil {
+			s.logger.WithContext(ctx).WithError(err).Error("Failed to compute roll stats metrics")
+			return err
+		}
+
+		sides := attribute.Int("app.dice.sides", result.Sides)
+
+		for _, face := range result.Faces {
+			observer.ObserveInt64(faceCount, int64(face.Count), metric.WithAttributes(sides, attribute.String("app.dice.face", strconv.Itoa(face.Face))))
+		}
+		observer.ObserveFloat64(mean, result.Mean, metric.WithAttributes(sides))
+		observer.ObserveFloat64(variance, result.Variance, metric.WithAttributes(sides))
+		observer.ObserveInt64(longestStreak, int64(result.LongestStreak.Length), metric.WithAttributes(sides))
+		observer.ObserveFloat64(chiSquare, result.ChiSquare.Statistic, metric.WithAttributes(sides))
+		observer.ObserveFloat64(pValue, result.ChiSquare.PValue, metric.WithAttributes(sides))
+
+		return nil
+	}, faceCount, mean, variance, longestStreak, chiSquare, pValue)
+
+	return err
+}
+
+// termSides falls back to parsing the notation for rolls stored before terms carried their sides
+func termSides(term dice.TermResult) int {
+	if term.Sides > 0 || len(term.Dice) == 0 {
+		return term.Sides
+	}
+
+	expr, err := dice.Parse(term.Notation)
+	if err != nil || len(expr.Terms) == 0 || expr.Terms[0].Dice == nil {
+		return 0
+	}
+
+	return expr.Terms[0].Dice.Sides
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/demo/rolldice/internal/rolldice/models"
+	"github.com/demo/rolldice/internal/rolldice/repositories"
+	exception "github.com/demo/rolldice/pkg/exceptions"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	streamBufferSize = 256
+	MaxStreamReplay  = 1000
+)
+
+var (
+	ErrStreamLagging       = errors.New("subscriber fell behind the roll stream")
+	ErrStreamReplayTooLong = exception.New("stream.replay_too_long", exception.CategoryGone, "too many rolls since the last event ID, reload the history instead")
+)
+
+type StreamFilter struct {
+	SessionID string
+	RollerID  string
+}
+
+func (f StreamFilter) Matches(roll *models.Roll) bool {
+	if f.SessionID != "" && roll.SessionID != f.SessionID {
+		return false
+	}
+	if f.RollerID != "" && roll.RollerID != f.RollerID {
+		return false
+	}
+	return true
+}
+
+// StreamService fans every roll made by this instance out to the connected streams
+type StreamService struct {
+	tracer      trace.Tracer
+	logger      *logrus.Logger
+	repository  repositories.RollRepository
+	subscribers metric.Int64UpDownCounter
+
+	mu      sync.Mutex
+	streams map[*RollStream]struct{}
+}
+
+// RollStream delivers the rolls missed since the last event ID, then live rolls, on C
+type RollStream struct {
+	C <-chan *models.Roll
+
+	service   *StreamService
+	span      trace.Span
+	transport string
+	filter    StreamFilter
+	live      chan *models.Roll
+	done      chan struct{}
+	closeOnce sync.Once
+	err       error
+	sent      int
+}
+
+func NewStreamService(tracer trace.Tracer, logger *logrus.Logger, meter metric.Meter, repository repositories.RollRepository) (*StreamService, error) {
+	subscribers, err := meter.Int64UpDownCounter(
+		"app.rolls.stream.subscribers",
+		metric.WithDescription("Connected roll stream subscribers"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamService{
+		tracer:      tracer,
+		logger:      logger,
+		repository:  repository,
+		subscribers: subscribers,
+		streams:     map[*RollStream]struct{}{},
+	}, nil
+}
+
+// Broadcast hands rolls to every matching stream, a stream whose buffer is full is closed with ErrStreamLagging
+func (s *StreamService) Broadcast(rolls ...*models.Roll) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for stream := range s.streams {
+	rolls:
+		for _, roll := range rolls {
+			if !stream.filter.Matches(roll) {
+				continue
+			}
+
+			select {
+			case stream.live <- roll:
+			default:
+				s.remove(stream, ErrStreamLagging)
+				break rolls
+			}
+		}
+	}
+}
+
+// Subscribe opens a stream that lives until ctx is done or Close is called, its span covers the whole connection
+func (s *StreamService) Subscribe(ctx context.Context, transport string, filter StreamFilter, lastEventID string) (*RollStream, error) {
+	ctx, span := s.tracer.Start(ctx, "rolls.stream "+transport)
+
+	span.SetAttributes(
+		attribute.String("app.stream.transport", transport),
+		attribute.String("app.stream.session_id", filter.SessionID),
+		attribute.String("app.stream.roller_id", filter.RollerID),
+		attribute.String("app.stream.last_event_id", lastEventID),
+	)
+
+	out := make(chan *models.Roll)
+	stream := &RollStream{
+		C:         out,
+		service:   s,
+		span:      span,
+		transport: transport,
+		filter:    filter,
+		live:      make(chan *models.Roll, streamBufferSize),
+		done:      make(chan struct{}),
+	}
+
+	// Subscribe before loading the replay so no roll falls between the two
+	s.mu.Lock()
+	s.streams[stream] = struct{}{}
+	s.mu.Unlock()
+
+	s.subscribers.Add(ctx, 1, metric.WithAttributes(attribute.String("transport", transport)))
+
+	replay, err := s.replay(ctx, filter, lastEventID)
+	if err != nil {
+		stream.Close(err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("app.stream.replayed", len(replay)))
+
+	go stream.run(ctx, out, replay)
+
+	s.logger.WithContext(ctx).Infof("Roll stream opened over %s, replaying %d rolls", transport, len(replay))
+
+	return stream, nil
+}
+
+// Close ends every open stream so the servers holding them can drain, new streams are still accepted
+func (s *StreamService) Close(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for stream := range s.streams {
+		s.remove(stream, nil)
+	}
+
+	return nil
+}
+
+func (s *StreamService) replay(ctx context.Context, filter StreamFilter, lastEventID string) ([]*models.Roll, error) {
+	if lastEventID == "" {
+		return nil, nil
+	}
+
+	last, err := s.repository.FindByID(ctx, lastEventID)
+	if err != nil {
+		return nil, err
+	}
+
+	replay := []*models.Roll{}
+	err = s.repository.Each(ctx, last.CreatedAt, time.Now().UTC().Add(time.Second), func(roll *models.Roll) error {
+		if !rollAfter(roll, last) || !filter.Matches(roll) {
+			return nil
+		}
+		if len(replay) == MaxStreamReplay {
+			return ErrStreamReplayTooLong
+		}
+		replay = append(replay, roll)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return replay, nil
+}
+
+func (s *StreamService) remove(stream *RollStream, err error) {
+	if _, ok := s.streams[stream]; !ok {
+		return
+	}
+
+	delete(s.streams, stream)
+	stream.err = err
+	close(stream.done)
+}
+
+func (st *RollStream) run(ctx context.Context, out chan<- *models.Roll, replay []*models.Roll) {
+	defer close(out)
+
+	replayed := make(map[string]struct{}, len(replay))
+	for _, roll := range replay {
+		select {
+		case out <- roll:
+			replayed[roll.ID] = struct{}{}
+		case <-ctx.Done():
+			return
+		case <-st.done:
+			return
+		}
+	}
+
+	for {
+		select {
+		case roll := <-st.live:
+			// Rolls made while the replay was loading can show up in both
+			if _, ok := replayed[roll.ID]; ok {
+				continue
+			}
+			select {
+			case out <- roll:
+			case <-ctx.Done():
+				return
+			case <-st.done:
+				return
+			}
+		case <-ctx.Done():
+			return
+		case <-st.done:
+			return
+		}
+	}
+}
+
+// Sent records that a roll was written to the client
+func (st *RollStream) Sent() {
+	st.sent++
+}
+
+// Err is set once the service dropped the stream, e.g. ErrStreamLagging
+func (st *RollStream) Err() error {
+	select {
+	case <-st.done:
+		return st.err
+	default:
+		return nil
+	}
+}
+
+// Close unsubscribes and ends the stream span, err is recorded when the stream ended abnormally
+func (st *RollStream) Close(err error) {
+	st.closeOnce.Do(func() {
+		s := st.service
+
+		s.mu.Lock()
+		s.remove(st, err)
+		s.mu.Unlock()
+
+		if err == nil {
+			err = st.err
+		}
+		if err != nil {
+			st.span.RecordError(err)
+			st.span.SetStatus(codes.Error, err.Error())
+		}
+
+		st.span.SetAttributes(attribute.Int("app.stream.sent", st.sent))
+		st.span.End()
+
+		s.subscribers.Add(context.Background(), -1, metric.WithAttributes(attribute.String("transport", st.transport)))
+	})
+}
+
+func rollAfter(roll, last *models.Roll) bool {
+	if roll.CreatedAt.Equal(last.CreatedAt) {
+		return roll.ID > last.ID
+	}
+	return roll.CreatedAt.After(last.CreatedAt)
+}
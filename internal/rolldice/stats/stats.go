@@ -0,0 +1,188 @@
+package stats
+
+import (
+	"math"
+)
+
+type FaceCount struct {
+	Face     int     `json:"face"`
+	Count    int     `json:"count"`
+	Expected float64 `json:"expected"`
+}
+
+type Streak struct {
+	Face   int `json:"face"`
+	Length int `json:"length"`
+}
+
+type ChiSquare struct {
+	Statistic        float64 `json:"statistic"`
+	DegreesOfFreedom int     `json:"degrees_of_freedom"`
+	PValue           float64 `json:"p_value"`
+}
+
+type Summary struct {
+	Sides         int         `json:"sides"`
+	Dice          int         `json:"dice"`
+	Faces         []FaceCount `json:"faces"`
+	Mean          float64     `json:"mean"`
+	ExpectedMean  float64     `json:"expected_mean"`
+	Variance      float64     `json:"variance"`
+	LongestStreak Streak      `json:"longest_streak"`
+	Streaks       []Streak    `json:"longest_streaks"`
+	ChiSquare     ChiSquare   `json:"chi_square"`
+}
+
+// Accumulator collects die values of a single die size in the order they were rolled
+type Accumulator struct {
+	sides   int
+	counts  []int
+	streaks []int
+	n       int
+	mean    float64
+	m2      float64
+	last    int
+	run     int
+}
+
+func NewAccumulator(sides int) *Accumulator {
+	return &Accumulator{
+		sides:   sides,
+		counts:  make([]int, sides+1),
+		streaks: make([]int, sides+1),
+	}
+}
+
+func (a *Accumulator) Add(value int) {
+	if value < 1 || value > a.sides {
+		return
+	}
+
+	a.counts[value]++
+
+	// Welford's online algorithm keeps the variance numerically stable
+	a.n++
+	delta := float64(value) - a.mean
+	a.mean += delta / float64(a.n)
+	a.m2 += delta * (float64(value) - a.mean)
+
+	if value == a.last {
+		a.run++
+	} else {
+		a.last = value
+		a.run = 1
+	}
+	if a.run > a.streaks[value] {
+		a.streaks[value] = a.run
+	}
+}
+
+func (a *Accumulator) Summary() Summary {
+	expected := float64(a.n) / float64(a.sides)
+
+	summary := Summary{
+		Sides:        a.sides,
+		Dice:         a.n,
+		Faces:        make([]FaceCount, 0, a.sides),
+		Mean:         a.mean,
+		ExpectedMean: float64(a.sides+1) / 2,
+		Streaks:      make([]Streak, 0, a.sides),
+		ChiSquare: ChiSquare{
+			DegreesOfFreedom: a.sides - 1,
+			PValue:           1,
+		},
+	}
+
+	if a.n > 1 {
+		summary.Variance = a.m2 / float64(a.n-1)
+	}
+
+	for face := 1; face <= a.sides; face++ {
+		summary.Faces = append(summary.Faces, FaceCount{Face: face, Count: a.counts[face], Expected: expected})
+
+		streak := Streak{Face: face, Length: a.streaks[face]}
+		summary.Streaks = append(summary.Streaks, streak)
+		if streak.Length > summary.LongestStreak.Length {
+			summary.LongestStreak = streak
+		}
+
+		if expected > 0 {
+			diff := float64(a.counts[face]) - expected
+			summary.ChiSquare.Statistic += diff * diff / expected
+		}
+	}
+
+	if a.n > 0 && a.sides > 1 {
+		summary.ChiSquare.PValue = ChiSquarePValue(summary.ChiSquare.Statistic, summary.ChiSquare.DegreesOfFreedom)
+	}
+
+	return summary
+}
+
+// ChiSquarePValue is the probability of a statistic at least this large for a fair die
+func ChiSquarePValue(statistic float64, degreesOfFreedom int) float64 {
+	if statistic <= 0 {
+		return 1
+	}
+	return upperRegularizedGamma(float64(degreesOfFreedom)/2, statistic/2)
+}
+
+const (
+	gammaEpsilon    = 1e-14
+	gammaIterations = 500
+)
+
+// upperRegularizedGamma computes Q(a, x) with the series for x < a+1 and the continued fraction otherwise
+func upperRegularizedGamma(a, x float64) float64 {
+	if x < a+1 {
+		return 1 - lowerGammaSeries(a, x)
+	}
+	return upperGammaFraction(a, x)
+}
+
+func lowerGammaSeries(a, x float64) float64 {
+	lgamma, _ := math.Lgamma(a)
+
+	sum := 1 / a
+	term := sum
+	for n := 1; n < gammaIterations; n++ {
+		term *= x / (a + float64(n))
+		sum += term
+		if math.Abs(term) < math.Abs(sum)*gammaEpsilon {
+			break
+		}
+	}
+
+	return sum * math.Exp(-x+a*math.Log(x)-lgamma)
+}
+
+func upperGammaFraction(a, x float64) float64 {
+	const tiny = 1e-300
+	lgamma, _ := math.Lgamma(a)
+
+	// Modified Lentz's method
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < gammaIterations; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < gammaEpsilon {
+			break
+		}
+	}
+
+	return math.Exp(-x+a*math.Log(x)-lgamma) * h
+}
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+// Component is a part of the application with a lifecycle, Start must not block:
+// long-running work such as serving belongs in App.Go
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+	// Timeout bounds Start and Stop separately, DefaultTimeout when zero
+	Timeout time.Duration
+}
+
+// App starts components in registration order and stops them in reverse order on SIGINT or SIGTERM,
+// so a component is always stopped before the ones it depends on
+type App struct {
+	name       string
+	components []Component
+	failures   chan error
+	wg         sync.WaitGroup
+}
+
+func New(name string) *App {
+	return &App{
+		name:     name,
+		failures: make(chan error, 1),
+	}
+}
+
+func (a *App) Append(components ...Component) {
+	a.components = append(a.components, components...)
+}
+
+// Go runs fn in the background, an error returned by fn shuts the application down
+func (a *App) Go(name string, fn func() error) {
+	a.wg.Add(1)
+
+	go func() {
+		defer a.wg.Done()
+
+		if err := fn(); err != nil {
+			select {
+			case a.failures <- fmt.Errorf("%s: %w", name, err):
+			default:
+			}
+		}
+	}()
+}
+
+// Run blocks until ctx is done, a signal arrives or a background function fails, then stops every started component
+func (a *App) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	started := 0
+	var runErr error
+
+	for _, component := range a.components {
+		if err := a.start(ctx, component); err != nil {
+			runErr = fmt.Errorf("failed to start %s: %w", component.Name, err)
+			break
+		}
+		started++
+	}
+
+	if runErr == nil {
+		log.Printf("%s is running", a.name)
+
+		select {
+		case <-ctx.Done():
+			log.Printf("%s is shutting down", a.name)
+		case err := <-a.failures:
+			runErr = err
+			log.Printf("%s is shutting down: %v", a.name, err)
+		}
+	}
+
+	// Signals received from now on fall back to the default behaviour, a second Ctrl+C kills the process
+	stop()
+
+	errs := []error{runErr}
+	for i := started - 1; i >= 0; i-- {
+		errs = append(errs, a.stop(a.components[i]))
+	}
+
+	a.wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (a *App) start(ctx context.Context, component Component) error {
+	if component.Start == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout(component))
+	defer cancel()
+
+	return component.Start(ctx)
+}
+
+func (a *App) stop(component Component) error {
+	if component.Stop == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout(component))
+	defer cancel()
+
+	begin := time.Now()
+
+	if err := component.Stop(ctx); err != nil {
+		log.Printf("Failed to stop %s after %s: %v", component.Name, time.Since(begin), err)
+		return fmt.Errorf("failed to stop %s: %w", component.Name, err)
+	}
+
+	log.Printf("Stopped %s in %s", component.Name, time.Since(begin))
+
+	return nil
+}
+
+func timeout(component Component) time.Duration {
+	if component.Timeout > 0 {
+		return component.Timeout
+	}
+	return DefaultTimeout
+}
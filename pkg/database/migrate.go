@@ -0,0 +1,58 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type Migration struct {
+	Name      string
+	Statement string
+}
+
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	name       TEXT PRIMARY KEY,
+	applied_at INTEGER NOT NULL
+)`
+
+// Migrate applies, in order, every migration that has not been applied yet
+func (s *SQLite) Migrate(ctx context.Context, migrations []Migration) error {
+	if _, err := s.DB.ExecContext(ctx, createMigrationsTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	for _, migration := range migrations {
+		if err := s.apply(ctx, migration); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", migration.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *SQLite) apply(ctx context.Context, migration Migration) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var applied int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE name = ?`, migration.Name).Scan(&applied); err != nil {
+		return err
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, migration.Statement); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (name, applied_at) VALUES (?, ?)`, migration.Name, time.Now().UnixNano()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	_ "modernc.org/sqlite"
+)
+
+type SQLite struct {
+	DB     *sql.DB
+	Name   string
+	tracer trace.Tracer
+}
+
+func OpenSQLite(path string, tracer trace.Tracer) (*SQLite, error) {
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)", path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+
+	// SQLite allows a single writer, sharing one connection avoids "database is locked" errors
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to SQLite database: %w", err)
+	}
+
+	return &SQLite{
+		DB:     db,
+		Name:   filepath.Base(path),
+		tracer: tracer,
+	}, nil
+}
+
+// StartSpan starts a client span carrying the database semantic convention attributes
+func (s *SQLite) StartSpan(ctx context.Context, operation, table, statement string) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, operation+" "+table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemSqlite,
+			semconv.DBNameKey.String(s.Name),
+			semconv.DBOperationKey.String(operation),
+			semconv.DBSQLTableKey.String(table),
+			semconv.DBStatementKey.String(statement),
+		),
+	)
+}
+
+// EndSpan records err on the span, if any, and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (s *SQLite) Close() error {
+	return s.DB.Close()
+}
+
+// Ping checks the database still answers, it is not traced as probes call it every few seconds
+func (s *SQLite) Ping(ctx context.Context) error {
+	return s.DB.PingContext(ctx)
+}
@@ -1,9 +1,120 @@
 package exception
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 )
 
+type Category string
+
+const (
+	CategoryValidation      Category = "validation"
+	CategoryUnauthenticated Category = "unauthenticated"
+	CategoryForbidden       Category = "forbidden"
+	CategoryNotFound        Category = "not_found"
+	CategoryConflict        Category = "conflict"
+	CategoryGone            Category = "gone"
+	CategoryUnprocessable   Category = "unprocessable"
+	CategoryRateLimited     Category = "rate_limited"
+	CategoryUnavailable     Category = "unavailable"
+	CategoryInternal        Category = "internal"
+)
+
+var categoryStatus = map[Category]int{
+	CategoryValidation:      http.StatusBadRequest,
+	CategoryUnauthenticated: http.StatusUnauthorized,
+	CategoryForbidden:       http.StatusForbidden,
+	CategoryNotFound:        http.StatusNotFound,
+	CategoryConflict:        http.StatusConflict,
+	CategoryGone:            http.StatusGone,
+	CategoryUnprocessable:   http.StatusUnprocessableEntity,
+	CategoryRateLimited:     http.StatusTooManyRequests,
+	CategoryUnavailable:     http.StatusServiceUnavailable,
+	CategoryInternal:        http.StatusInternalServerError,
+}
+
+var (
+	ErrInvalidRequest  = New("request.invalid", CategoryValidation, "invalid request")
+	ErrUnauthenticated = New("auth.unauthenticated", CategoryUnauthenticated, "authentication required")
+	ErrRateLimited     = New("request.rate_limited", CategoryRateLimited, "rate limit exceeded, retry later")
+	ErrInternal        = New("internal", CategoryInternal, "internal error")
+)
+
+// Error is a domain error: Code identifies it for clients and dashboards, Message is safe to show to clients
+// while the wrapped cause, which may hold internals such as broker addresses, only goes to logs and spans
+type Error struct {
+	Code      string
+	Category  Category
+	Message   string
+	Retryable bool
+	cause     error
+}
+
+// New creates an error that is retryable when its category is rate_limited or unavailable
+func New(code string, category Category, message string) *Error {
+	return &Error{
+		Code:      code,
+		Category:  category,
+		Message:   message,
+		Retryable: category == CategoryRateLimited || category == CategoryUnavailable,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.cause == nil {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %v", e.Message, e.cause)
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is matches errors of the same code, so errors.Is works on copies made by Wrap and WithMessage
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of e caused by cause
+func (e *Error) Wrap(cause error) *Error {
+	wrapped := *e
+	wrapped.cause = cause
+	return &wrapped
+}
+
+// WithMessage returns a copy of e with a more specific public message
+func (e *Error) WithMessage(message string) *Error {
+	copied := *e
+	copied.Message = message
+	return &copied
+}
+
+// WithRetryable returns a copy of e that clients may or may not retry as is
+func (e *Error) WithRetryable(retryable bool) *Error {
+	copied := *e
+	copied.Retryable = retryable
+	return &copied
+}
+
+func (e *Error) Status() int {
+	if status, ok := categoryStatus[e.Category]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// From finds the domain error in err's chain, any other error is internal and keeps its message private
+func From(err error) *Error {
+	var exception *Error
+	if errors.As(err, &exception) {
+		return exception
+	}
+	return ErrInternal.Wrap(err)
+}
+
 func Print(err error, message string) {
 	if err != nil {
 		fmt.Printf("%s : %v", message, err)
@@ -0,0 +1,37 @@
+package exception
+
+import (
+	"net/http"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:problem-type:"
+)
+
+// Problem is an RFC 7807 problem details body, extended with the error code and whether a retry may succeed
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	Retryable bool   `json:"retryable"`
+	TraceID   string `json:"trace_id,omitempty"`
+}
+
+// Problem describes e without its cause, instance is the path of the request that failed
+func (e *Error) Problem(instance string) Problem {
+	status := e.Status()
+
+	return Problem{
+		Type:      problemTypePrefix + e.Code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    e.Message,
+		Instance:  instance,
+		Code:      e.Code,
+		Retryable: e.Retryable,
+	}
+}
@@ -0,0 +1,152 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+
+	DefaultCheckTimeout = 2 * time.Second
+)
+
+// CheckFunc reports a dependency as unavailable by returning an error
+type CheckFunc func(ctx context.Context) error
+
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker runs every readiness check concurrently, each bounded by its own timeout
+type Checker struct {
+	timeout time.Duration
+	checks  []namedCheck
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+
+	return &Checker{timeout: timeout}
+}
+
+func (c *Checker) Add(name string, check CheckFunc) {
+	c.checks = append(c.checks, namedCheck{name, check})
+}
+
+// Check is up only when every check passed
+func (c *Checker) Check(ctx context.Context) Report {
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(c.checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range c.checks {
+		wg.Add(1)
+
+		go func(check namedCheck) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			begin := time.Now()
+			err := check.check(ctx)
+
+			result := CheckResult{
+				Status:    StatusUp,
+				LatencyMs: float64(time.Since(begin).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[check.name] = result
+			if err != nil {
+				report.Status = StatusDown
+			}
+		}(check)
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+// Cached runs check at most once per ttl and returns the last result meanwhile, for checks against rate-limited APIs
+func Cached(check CheckFunc, ttl time.Duration) CheckFunc {
+	var (
+		mu        sync.Mutex
+		checkedAt time.Time
+		lastErr   error
+	)
+
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !checkedAt.IsZero() && time.Since(checkedAt) < ttl {
+			return lastErr
+		}
+
+		lastErr = check(ctx)
+		checkedAt = time.Now()
+
+		return lastErr
+	}
+}
+
+type HealthHandler struct {
+	checker *Checker
+}
+
+func InitHealthHandler(e *echo.Echo, checker *Checker) {
+	handler := &HealthHandler{
+		checker,
+	}
+
+	e.GET(LivenessPath, handler.Liveness)
+	e.GET(ReadinessPath, handler.Readiness)
+}
+
+// Liveness only tells the process still serves requests, dependencies going down must not get it restarted
+func (h *HealthHandler) Liveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, Report{Status: StatusUp})
+}
+
+// Readiness answers 503 while any dependency is down so traffic is routed to other replicas
+func (h *HealthHandler) Readiness(c echo.Context) error {
+	report := h.checker.Check(c.Request().Context())
+
+	if report.Status != StatusUp {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
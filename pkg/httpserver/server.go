@@ -0,0 +1,128 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+const (
+	ClientAuthRequire  = "require"
+	ClientAuthOptional = "optional"
+)
+
+// Config of a server, TLS is enabled by TLSCertFile and TLSKeyFile and client certificates by TLSClientCAFile
+type Config struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	BodyLimit         string
+	TLSCertFile       string
+	TLSKeyFile        string
+	TLSClientCAFile   string
+	// TLSClientAuth is ClientAuthRequire or ClientAuthOptional, optional certificates are still verified when presented
+	TLSClientAuth string
+}
+
+func (c Config) TLSEnabled() bool {
+	return c.TLSCertFile != ""
+}
+
+// New builds a server for handler, without TLS it also accepts HTTP/2 over cleartext (h2c)
+func New(config Config, handler http.Handler) (*http.Server, error) {
+	server := &http.Server{
+		Addr:              config.Addr,
+		Handler:           handler,
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+		MaxHeaderBytes:    config.MaxHeaderBytes,
+	}
+
+	if !config.TLSEnabled() {
+		server.Handler = h2c.NewHandler(handler, &http2.Server{IdleTimeout: config.IdleTimeout})
+		return server, nil
+	}
+
+	tlsConfig, err := TLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	server.TLSConfig = tlsConfig
+
+	return server, nil
+}
+
+// TLSConfig is the server side TLS of config, with client certificate verification when TLSClientCAFile is set.
+// It is nil without TLS
+func TLSConfig(config Config) (*tls.Config, error) {
+	if !config.TLSEnabled() {
+		return nil, nil
+	}
+	return newTLSConfig(config)
+}
+
+// ListenAndServe serves over TLS when the server has a TLS config, HTTP/2 is then negotiated with ALPN
+func ListenAndServe(server *http.Server) error {
+	var err error
+	if server.TLSConfig != nil {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func newTLSConfig(config Config) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if config.TLSClientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(config.TLSClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TLS client CA: %w", err)
+	}
+
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in TLS client CA %s", config.TLSClientCAFile)
+	}
+
+	tlsConfig.ClientCAs = clientCAs
+
+	switch config.TLSClientAuth {
+	case ClientAuthOptional:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequire, "":
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("invalid TLS client auth %q, expected %s or %s", config.TLSClientAuth, ClientAuthRequire, ClientAuthOptional)
+	}
+
+	return tlsConfig, nil
+}
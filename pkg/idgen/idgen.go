@@ -0,0 +1,30 @@
+package idgen
+
+import (
+	"fmt"
+)
+
+const (
+	ULIDGeneratorName      = "ulid"
+	UUIDv7GeneratorName    = "uuidv7"
+	SnowflakeGeneratorName = "snowflake"
+)
+
+// Generator creates unique IDs whose lexical order follows their creation time
+type Generator interface {
+	NewID() string
+	Name() string
+}
+
+func NewGenerator(name string, nodeID int64) (Generator, error) {
+	switch name {
+	case "", ULIDGeneratorName:
+		return NewULIDGenerator(), nil
+	case UUIDv7GeneratorName:
+		return NewUUIDv7Generator(), nil
+	case SnowflakeGeneratorName:
+		return NewSnowflakeGenerator(nodeID)
+	default:
+		return nil, fmt.Errorf("unknown ID generator %q", name)
+	}
+}
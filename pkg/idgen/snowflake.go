@@ -0,0 +1,70 @@
+package idgen
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+	maxNodeID    = 1<<nodeBits - 1
+	maxSequence  = 1<<sequenceBits - 1
+)
+
+// snowflakeEpoch is 2024-01-01T00:00:00Z, leaving 41 bits of milliseconds for about 69 years
+var snowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// SnowflakeGenerator packs a millisecond timestamp, the node ID and a per-millisecond sequence into 63 bits
+type SnowflakeGenerator struct {
+	mu       sync.Mutex
+	nodeID   int64
+	lastTime int64
+	sequence int64
+}
+
+func NewSnowflakeGenerator(nodeID int64) (*SnowflakeGenerator, error) {
+	if nodeID < 0 || nodeID > maxNodeID {
+		return nil, fmt.Errorf("snowflake node ID must be between 0 and %d", maxNodeID)
+	}
+
+	return &SnowflakeGenerator{nodeID: nodeID}, nil
+}
+
+func (g *SnowflakeGenerator) Name() string {
+	return SnowflakeGeneratorName
+}
+
+// NewID returns the ID zero-padded to 19 digits so string order matches numeric order
+func (g *SnowflakeGenerator) NewID() string {
+	return fmt.Sprintf("%019d", g.next())
+}
+
+func (g *SnowflakeGenerator) next() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Since(snowflakeEpoch).Milliseconds()
+
+	// Never go back in time, a clock moved backwards keeps using the last timestamp
+	if now < g.lastTime {
+		now = g.lastTime
+	}
+
+	if now == g.lastTime {
+		g.sequence = (g.sequence + 1) & maxSequence
+		if g.sequence == 0 {
+			for now <= g.lastTime {
+				time.Sleep(time.Millisecond / 10)
+				now = time.Since(snowflakeEpoch).Milliseconds()
+			}
+		}
+	} else {
+		g.sequence = 0
+	}
+
+	g.lastTime = now
+
+	return now<<(nodeBits+sequenceBits) | g.nodeID<<sequenceBits | g.sequence
+}
@@ -0,0 +1,19 @@
+package idgen
+
+import (
+	"github.com/google/uuid"
+)
+
+type UUIDv7Generator struct{}
+
+func NewUUIDv7Generator() *UUIDv7Generator {
+	return &UUIDv7Generator{}
+}
+
+func (g *UUIDv7Generator) Name() string {
+	return UUIDv7GeneratorName
+}
+
+func (g *UUIDv7Generator) NewID() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
@@ -0,0 +1,287 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/dnwe/otelsarama"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultLinger      = 5 * time.Millisecond
+	DefaultBatchSize   = 100
+	DefaultMaxInFlight = 1000
+)
+
+var errProducerClosed = errors.New("kafka producer closed")
+
+// AsyncConfig tunes the asynchronous producer, zero values fall back to the defaults above and no compression
+type AsyncConfig struct {
+	// Linger is how long a message may wait for others to fill its batch
+	Linger time.Duration
+	// BatchSize is the number of messages that sends a batch without waiting for Linger
+	BatchSize int
+	// Compression is none, gzip, snappy, lz4 or zstd
+	Compression string
+	// MaxInFlight bounds the messages waiting for an acknowledgment, PublishAsync blocks once it is reached
+	MaxInFlight int
+}
+
+// Delivery is the future of an asynchronous publish, it completes once the broker acknowledged the message
+// or the producer gave up on it
+type Delivery struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+
+	ctx     context.Context
+	span    trace.Span
+	message Message
+	done    chan struct{}
+	err     error
+}
+
+// CompletedDelivery is a Delivery that completed with err, for doubles of PublishAsync
+func CompletedDelivery(topic string, err error) *Delivery {
+	delivery := &Delivery{Topic: topic, done: make(chan struct{}), err: err}
+	close(delivery.done)
+	return delivery
+}
+
+func (d *Delivery) Done() <-chan struct{} {
+	return d.done
+}
+
+// Err is the outcome of the publish, only valid once Done is closed
+func (d *Delivery) Err() error {
+	return d.err
+}
+
+// Wait blocks until the publish completes or ctx is done, the message may still be delivered in the latter case
+func (d *Delivery) Wait(ctx context.Context) error {
+	select {
+	case <-d.done:
+		return d.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func newAsyncProducerConfig(client ClientConfig, async AsyncConfig, idempotent bool) (*sarama.Config, error) {
+	config, err := createProducerConfig(client, idempotent)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Producer.Return.Errors = true
+	config.Producer.Flush.Frequency = async.Linger
+	config.Producer.Flush.Messages = async.BatchSize
+	config.ChannelBufferSize = async.MaxInFlight
+
+	if async.Compression != "" {
+		if err := config.Producer.Compression.UnmarshalText([]byte(async.Compression)); err != nil {
+			return nil, fmt.Errorf("invalid Kafka compression %q: %w", async.Compression, err)
+		}
+	}
+
+	if config.Producer.Compression == sarama.CompressionZSTD && !config.Version.IsAtLeast(sarama.V2_1_0_0) {
+		// zstd needs produce requests of version 7
+		config.Version = sarama.V2_1_0_0
+	}
+
+	return config, config.Validate()
+}
+
+func (a AsyncConfig) withDefaults() AsyncConfig {
+	if a.Linger <= 0 {
+		a.Linger = DefaultLinger
+	}
+	if a.BatchSize <= 0 {
+		a.BatchSize = DefaultBatchSize
+	}
+	if a.MaxInFlight <= 0 {
+		a.MaxInFlight = DefaultMaxInFlight
+	}
+	return a
+}
+
+// asyncProducer resolves deliveries from the successes and errors of a sarama.AsyncProducer,
+// sarama hands every message back with the Metadata it was sent with, which is its Delivery
+type asyncProducer struct {
+	producer sarama.AsyncProducer
+	inFlight chan struct{}
+	// closeMu is held for reading while sending, so close never closes the input under a send
+	closeMu   sync.RWMutex
+	closed    bool
+	pendingMu sync.Mutex
+	pending   map[*Delivery]struct{}
+	wg        sync.WaitGroup
+	resolved  func(*Delivery)
+}
+
+func newAsyncProducer(brokers []string, config *sarama.Config, maxInFlight int, resolved func(*Delivery)) (*asyncProducer, error) {
+	producer, err := sarama.NewAsyncProducer(brokers, config)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &asyncProducer{
+		producer: otelsarama.WrapAsyncProducer(config, producer),
+		inFlight: make(chan struct{}, maxInFlight),
+		pending:  map[*Delivery]struct{}{},
+		resolved: resolved,
+	}
+
+	p.wg.Add(2)
+
+	go func() {
+		defer p.wg.Done()
+		for message := range p.producer.Successes() {
+			if delivery, ok := message.Metadata.(*Delivery); ok {
+				delivery.Partition = message.Partition
+				delivery.Offset = message.Offset
+				p.complete(delivery, nil)
+			}
+		}
+	}()
+
+	go func() {
+		defer p.wg.Done()
+		for producerErr := range p.producer.Errors() {
+			if delivery, ok := producerErr.Msg.Metadata.(*Delivery); ok {
+				p.complete(delivery, producerErr.Err)
+			}
+		}
+	}()
+
+	return p, nil
+}
+
+// send blocks while MaxInFlight messages are waiting for an acknowledgment, until ctx is done
+func (p *asyncProducer) send(ctx context.Context, delivery *Delivery, message *sarama.ProducerMessage) error {
+	select {
+	case p.inFlight <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	p.closeMu.RLock()
+	defer p.closeMu.RUnlock()
+
+	if p.closed {
+		<-p.inFlight
+		return errProducerClosed
+	}
+
+	p.pendingMu.Lock()
+	p.pending[delivery] = struct{}{}
+	p.pendingMu.Unlock()
+
+	message.Metadata = delivery
+	p.producer.Input() <- message
+
+	return nil
+}
+
+func (p *asyncProducer) complete(delivery *Delivery, err error) {
+	p.pendingMu.Lock()
+	_, ok := p.pending[delivery]
+	delete(p.pending, delivery)
+	p.pendingMu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	<-p.inFlight
+	delivery.resolve(err)
+	p.resolved(delivery)
+}
+
+// close flushes buffered messages, deliveries sarama dropped while closing fail with errProducerClosed
+func (p *asyncProducer) close() error {
+	p.closeMu.Lock()
+	p.closed = true
+	p.closeMu.Unlock()
+
+	err := p.producer.Close()
+	p.wg.Wait()
+
+	p.pendingMu.Lock()
+	dropped := p.pending
+	p.pending = map[*Delivery]struct{}{}
+	p.pendingMu.Unlock()
+
+	for delivery := range dropped {
+		<-p.inFlight
+		delivery.resolve(errProducerClosed)
+		p.resolved(delivery)
+	}
+
+	return err
+}
+
+func (d *Delivery) resolve(err error) {
+	if err != nil {
+		d.err = ErrPublishFailed.Wrap(fmt.Errorf("failed to publish message to Kafka: %w", err))
+		d.span.RecordError(err)
+		d.span.SetStatus(codes.Error, err.Error())
+	} else {
+		d.span.SetAttributes(
+			attribute.Int("messaging.kafka.destination.partition", int(d.Partition)),
+			attribute.Int64("messaging.kafka.message.offset", d.Offset),
+		)
+	}
+
+	d.span.End()
+	close(d.done)
+}
+
+// PublishAsync hands the message to the asynchronous producer and returns without waiting for the broker,
+// it blocks while MaxInFlight messages are in flight. Without WithAsync the message is published synchronously
+// and the returned delivery is already complete
+func (p *KafkaProducer) PublishAsync(ctx context.Context, topic string, message Message) (*Delivery, error) {
+	ctx, span := p.tracer.Start(ctx, "publish to kafka", trace.WithAttributes(attribute.Bool("app.kafka.async", p.async != nil)))
+
+	delivery := &Delivery{
+		Topic:   topic,
+		ctx:     ctx,
+		span:    span,
+		message: message,
+		done:    make(chan struct{}),
+	}
+
+	producerMessage := p.newMessage(ctx, topic, message)
+
+	if p.async == nil {
+		partition, offset, err := p.producer.SendMessage(producerMessage)
+		delivery.Partition, delivery.Offset = partition, offset
+		delivery.resolve(err)
+		p.logDelivery(delivery)
+		return delivery, nil
+	}
+
+	if err := p.async.send(ctx, delivery, producerMessage); err != nil {
+		delivery.resolve(err)
+		p.logDelivery(delivery)
+		return nil, delivery.err
+	}
+
+	return delivery, nil
+}
+
+func (p *KafkaProducer) logDelivery(delivery *Delivery) {
+	if delivery.err != nil {
+		p.logError(delivery.ctx, delivery.Topic, delivery.message.Key, delivery.message.Value, delivery.err)
+		return
+	}
+
+	p.logSuccess(delivery.ctx, delivery.Topic, delivery.message.Key, delivery.message.Value, delivery.Partition, delivery.Offset)
+}
@@ -0,0 +1,202 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+)
+
+const (
+	SASLNone        = "none"
+	SASLPlain       = sarama.SASLTypePlaintext
+	SASLScramSHA256 = sarama.SASLTypeSCRAMSHA256
+	SASLScramSHA512 = sarama.SASLTypeSCRAMSHA512
+	SASLOAuthBearer = sarama.SASLTypeOAuth
+)
+
+// ClientConfig is how every producer, consumer and health check reaches and authenticates to the brokers
+type ClientConfig struct {
+	Brokers []string
+	SASL    SASLConfig
+	TLS     TLSConfig
+}
+
+// SASLConfig authenticates with Username and Password, or with the tokens of TokenSource for OAUTHBEARER
+type SASLConfig struct {
+	// Mechanism is none, PLAIN, SCRAM-SHA-256, SCRAM-SHA-512 or OAUTHBEARER, empty is none
+	Mechanism   string
+	Username    string
+	Password    string
+	TokenSource TokenSource
+}
+
+// TLSConfig trusts the system roots unless CAFile is set, CertFile and KeyFile present a client certificate
+type TLSConfig struct {
+	Enabled    bool
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
+// TokenSource is asked for an OAUTHBEARER token on every new connection, so it can refresh expiring tokens
+type TokenSource interface {
+	Token() (string, error)
+}
+
+type TokenSourceFunc func() (string, error)
+
+func (f TokenSourceFunc) Token() (string, error) {
+	return f()
+}
+
+// StaticTokenSource always returns token
+func StaticTokenSource(token string) TokenSource {
+	return TokenSourceFunc(func() (string, error) {
+		return token, nil
+	})
+}
+
+// FileTokenSource reads the token from path on every call, a token rotated on disk is picked up by the next connection
+func FileTokenSource(path string) TokenSource {
+	return TokenSourceFunc(func() (string, error) {
+		token, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read Kafka OAuth token: %w", err)
+		}
+		return strings.TrimSpace(string(token)), nil
+	})
+}
+
+// newSaramaConfig is the base of every sarama configuration, with the security settings of the client
+func (c ClientConfig) newSaramaConfig() (*sarama.Config, error) {
+	config := sarama.NewConfig()
+
+	if err := c.SASL.apply(config); err != nil {
+		return nil, err
+	}
+
+	if err := c.TLS.apply(config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (s SASLConfig) apply(config *sarama.Config) error {
+	switch s.Mechanism {
+	case "", SASLNone:
+		return nil
+	case SASLPlain, SASLScramSHA256, SASLScramSHA512:
+		if s.Username == "" {
+			return fmt.Errorf("kafka SASL %s requires a username", s.Mechanism)
+		}
+	case SASLOAuthBearer:
+		if s.TokenSource == nil {
+			return errors.New("kafka SASL OAUTHBEARER requires a token source")
+		}
+	default:
+		return fmt.Errorf("unsupported Kafka SASL mechanism %q", s.Mechanism)
+	}
+
+	config.Net.SASL.Enable = true
+	config.Net.SASL.Mechanism = sarama.SASLMechanism(s.Mechanism)
+	config.Net.SASL.User = s.Username
+	config.Net.SASL.Password = s.Password
+
+	switch s.Mechanism {
+	case SASLScramSHA256:
+		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &scramClient{hash: scram.SHA256} }
+	case SASLScramSHA512:
+		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &scramClient{hash: scram.SHA512} }
+	case SASLOAuthBearer:
+		config.Net.SASL.TokenProvider = tokenProvider{s.TokenSource}
+	}
+
+	return nil
+}
+
+func (t TLSConfig) apply(config *sarama.Config) error {
+	if !t.Enabled {
+		if t.CAFile != "" || t.CertFile != "" || t.ServerName != "" {
+			return errors.New("kafka TLS settings require TLS to be enabled")
+		}
+		return nil
+	}
+
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return errors.New("kafka TLS client certificate and key must be set together")
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: t.ServerName,
+	}
+
+	if t.CAFile != "" {
+		ca, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read Kafka CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return fmt.Errorf("no certificate found in Kafka CA file %s", t.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if t.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load Kafka client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	config.Net.TLS.Enable = true
+	config.Net.TLS.Config = tlsConfig
+
+	return nil
+}
+
+// scramClient runs the SCRAM conversation sarama drives through Begin and Step
+type scramClient struct {
+	hash         scram.HashGeneratorFcn
+	conversation *scram.ClientConversation
+}
+
+func (c *scramClient) Begin(username, password, authzID string) error {
+	client, err := c.hash.NewClient(username, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.conversation = client.NewConversation()
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conversation.Step(challenge)
+}
+
+func (c *scramClient) Done() bool {
+	return c.conversation.Done()
+}
+
+type tokenProvider struct {
+	source TokenSource
+}
+
+func (p tokenProvider) Token() (*sarama.AccessToken, error) {
+	token, err := p.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	return &sarama.AccessToken{Token: token}, nil
+}
@@ -0,0 +1,303 @@
+package kafka
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/dnwe/otelsarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CloudEventsMode is how a message carries its CloudEvents envelope, see the Kafka protocol binding of CloudEvents 1.0
+type CloudEventsMode string
+
+const (
+	// CloudEventsBinary keeps the value as is and puts the attributes in ce_ headers
+	CloudEventsBinary CloudEventsMode = "binary"
+	// CloudEventsStructured replaces the value with a JSON envelope holding the attributes and the data
+	CloudEventsStructured CloudEventsMode = "structured"
+)
+
+const (
+	CloudEventsSpecVersion = "1.0"
+	ContentTypeHeader      = "content-type"
+	CloudEventsContentType = "application/cloudevents+json"
+	DefaultContentType     = "application/json"
+
+	cloudEventsHeaderPrefix = "ce_"
+	traceParentAttribute    = "traceparent"
+	traceStateAttribute     = "tracestate"
+)
+
+var ErrInvalidCloudEvent = errors.New("invalid CloudEvent")
+
+// Event is a consumed message with its CloudEvents attributes. A message published without an envelope
+// has no SpecVersion, only its ID, from the event ID header, and its Data
+type Event struct {
+	SpecVersion     string
+	ID              string
+	Source          string
+	Type            string
+	Subject         string
+	DataContentType string
+	DataSchema      string
+	Time            time.Time
+	Data            []byte
+	// Extensions holds the other attributes, e.g. traceparent and tracestate of the distributed tracing extension
+	Extensions map[string]string
+	Message    *sarama.ConsumerMessage
+}
+
+func (e *Event) IsCloudEvent() bool {
+	return e.SpecVersion != ""
+}
+
+// Link points to the trace the event was created in, carried by the distributed tracing extension,
+// which is not the trace of the publish when the event went through the outbox
+func (e *Event) Link() trace.Link {
+	carrier := propagation.MapCarrier{}
+	for _, attribute := range []string{traceParentAttribute, traceStateAttribute} {
+		if value, ok := e.Extensions[attribute]; ok {
+			carrier[attribute] = value
+		}
+	}
+
+	return trace.LinkFromContext(propagation.TraceContext{}.Extract(context.Background(), carrier))
+}
+
+// DecodeEvent reads the envelope of either mode, a value that is not a structured envelope is the data of the event
+func DecodeEvent(message *sarama.ConsumerMessage) (*Event, error) {
+	event := &Event{
+		Data:       message.Value,
+		Extensions: map[string]string{},
+		Message:    message,
+	}
+
+	for _, header := range message.Headers {
+		key := strings.ToLower(string(header.Key))
+
+		switch {
+		case key == ContentTypeHeader:
+			event.DataContentType = string(header.Value)
+		case key == EventIDHeader:
+			event.ID = string(header.Value)
+		case strings.HasPrefix(key, cloudEventsHeaderPrefix):
+			if err := event.setAttribute(strings.TrimPrefix(key, cloudEventsHeaderPrefix), string(header.Value)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if strings.HasPrefix(event.DataContentType, CloudEventsContentType) {
+		return event, event.decodeStructured(message.Value)
+	}
+
+	if event.SpecVersion != "" {
+		return event, event.validate()
+	}
+
+	return event, nil
+}
+
+func (e *Event) decodeStructured(value []byte) error {
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(value, &envelope); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCloudEvent, err)
+	}
+
+	e.DataContentType = ""
+	e.Data = nil
+
+	for name, raw := range envelope {
+		switch name {
+		case "data":
+			e.Data = raw
+		case "data_base64":
+			var encoded string
+			if err := json.Unmarshal(raw, &encoded); err != nil {
+				return fmt.Errorf("%w: data_base64: %w", ErrInvalidCloudEvent, err)
+			}
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return fmt.Errorf("%w: data_base64: %w", ErrInvalidCloudEvent, err)
+			}
+			e.Data = data
+		default:
+			var value any
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return fmt.Errorf("%w: %s: %w", ErrInvalidCloudEvent, name, err)
+			}
+			if err := e.setAttribute(name, fmt.Sprint(value)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return e.validate()
+}
+
+func (e *Event) setAttribute(name, value string) error {
+	switch name {
+	case "specversion":
+		e.SpecVersion = value
+	case "id":
+		e.ID = value
+	case "source":
+		e.Source = value
+	case "type":
+		e.Type = value
+	case "subject":
+		e.Subject = value
+	case "datacontenttype":
+		e.DataContentType = value
+	case "dataschema":
+		e.DataSchema = value
+	case "time":
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return fmt.Errorf("%w: time: %w", ErrInvalidCloudEvent, err)
+		}
+		e.Time = t
+	default:
+		e.Extensions[name] = value
+	}
+
+	return nil
+}
+
+func (e *Event) validate() error {
+	if e.SpecVersion != CloudEventsSpecVersion {
+		return fmt.Errorf("%w: unsupported specversion %q", ErrInvalidCloudEvent, e.SpecVersion)
+	}
+
+	if e.ID == "" || e.Source == "" || e.Type == "" {
+		return fmt.Errorf("%w: id, source and type are required", ErrInvalidCloudEvent)
+	}
+
+	return nil
+}
+
+// EventHandler adapts a handler of events to NewConsumer. Its span continues the trace of the message headers
+// and links to the trace the event was created in
+func EventHandler(tracer trace.Tracer, handle func(ctx context.Context, event *Event) error) func(*sarama.ConsumerMessage) error {
+	return func(message *sarama.ConsumerMessage) error {
+		ctx := otel.GetTextMapPropagator().Extract(context.Background(), otelsarama.NewConsumerMessageCarrier(message))
+
+		event, err := DecodeEvent(message)
+		if err != nil {
+			_, span := tracer.Start(ctx, "process event")
+			defer span.End()
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+
+		name := "process event"
+		if event.Type != "" {
+			name = "process " + event.Type
+		}
+
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithLinks(event.Link()),
+			trace.WithAttributes(
+				semconv.MessagingDestinationName(message.Topic),
+				semconv.CloudeventsEventID(event.ID),
+				semconv.CloudeventsEventSource(event.Source),
+				semconv.CloudeventsEventType(event.Type),
+			),
+		)
+		defer span.End()
+
+		if err := handle(ctx, event); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+
+		return nil
+	}
+}
+
+// cloudEvent wraps producerMessage in an envelope, the trace context of the extension is the one message was created in
+// when it carries one, the context it is published in otherwise
+func (p *KafkaProducer) cloudEvent(ctx context.Context, producerMessage *sarama.ProducerMessage, id string, message Message) {
+	eventType := message.Type
+	if eventType == "" {
+		eventType = producerMessage.Topic
+	}
+
+	contentType := message.ContentType
+	if contentType == "" {
+		contentType = DefaultContentType
+	}
+
+	eventTime := message.Time
+	if eventTime.IsZero() {
+		eventTime = time.Now()
+	}
+
+	traceContext := propagation.MapCarrier{}
+	if message.TraceContext != nil {
+		for _, attribute := range []string{traceParentAttribute, traceStateAttribute} {
+			if value, ok := message.TraceContext[attribute]; ok {
+				traceContext[attribute] = value
+			}
+		}
+	} else {
+		propagation.TraceContext{}.Inject(ctx, traceContext)
+	}
+
+	attributes := [][2]string{
+		{"specversion", CloudEventsSpecVersion},
+		{"id", id},
+		{"source", p.cloudEventsSource},
+		{"type", eventType},
+		{"time", eventTime.UTC().Format(time.RFC3339Nano)},
+	}
+	for _, attribute := range []string{traceParentAttribute, traceStateAttribute} {
+		if value := traceContext.Get(attribute); value != "" {
+			attributes = append(attributes, [2]string{attribute, value})
+		}
+	}
+
+	if p.cloudEventsMode == CloudEventsBinary {
+		for _, attribute := range attributes {
+			producerMessage.Headers = append(producerMessage.Headers, sarama.RecordHeader{Key: []byte(cloudEventsHeaderPrefix + attribute[0]), Value: []byte(attribute[1])})
+		}
+		producerMessage.Headers = append(producerMessage.Headers, sarama.RecordHeader{Key: []byte(ContentTypeHeader), Value: []byte(contentType)})
+		return
+	}
+
+	envelope := map[string]any{"datacontenttype": contentType}
+	for _, attribute := range attributes {
+		envelope[attribute[0]] = attribute[1]
+	}
+
+	// Other values, including JSON framed with a schema ID, are carried in base64
+	if isJSON(contentType) && json.Valid([]byte(message.Value)) {
+		envelope["data"] = json.RawMessage(message.Value)
+	} else {
+		envelope["data_base64"] = base64.StdEncoding.EncodeToString([]byte(message.Value))
+	}
+
+	value, _ := json.Marshal(envelope)
+
+	producerMessage.Value = sarama.ByteEncoder(value)
+	producerMessage.Headers = append(producerMessage.Headers, sarama.RecordHeader{Key: []byte(ContentTypeHeader), Value: []byte(CloudEventsContentType)})
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+	return mediaType == DefaultContentType || strings.HasSuffix(mediaType, "+json")
+}
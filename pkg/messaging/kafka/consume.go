@@ -2,16 +2,19 @@ package kafka
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
-	"sync"
 	"syscall"
 
 	"github.com/IBM/sarama"
 	"github.com/dnwe/otelsarama"
 )
 
+var ErrConsumerNotJoined = errors.New("consumer has not joined its group")
+
 // toggleConsumptionFlow pauses or resumes consumption based on the current state
 func toggleConsumptionFlow(client sarama.ConsumerGroup, isPause *bool) {
 	if *isPause {
@@ -23,37 +26,47 @@ func toggleConsumptionFlow(client sarama.ConsumerGroup, isPause *bool) {
 	*isPause = !*isPause
 }
 
-// createConsumerConfig creates a Kafka consumer configuration with SASL and TLS
-func createConsumerConfig(username, password string) *sarama.Config {
-	config := sarama.NewConfig()
+// createConsumerConfig creates a Kafka consumer configuration with the security settings of client
+func createConsumerConfig(client ClientConfig) (*sarama.Config, error) {
+	config, err := client.newSaramaConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	config.Consumer.Offsets.Initial = sarama.OffsetNewest
 	config.Version = sarama.V2_5_0_0
 	config.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRange()}
+	// Skip messages of aborted transactions, e.g. a batch of rolls that failed to publish
+	config.Consumer.IsolationLevel = sarama.ReadCommitted
 
-	// Configure SASL and TLS for secure connections
-	config.Net.SASL.Enable = true
-	config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
-	config.Net.SASL.User = username
-	config.Net.SASL.Password = password
-	config.Net.TLS.Enable = true
+	return config, nil
+}
 
-	return config
+// Consumer consumes topics as a member of a consumer group until it is stopped
+type Consumer struct {
+	client  sarama.ConsumerGroup
+	topics  []string
+	group   *KafkaConsumerGroupHandler
+	handler sarama.ConsumerGroupHandler
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
 }
 
-// StartConsumption starts Kafka consumer group and handles message processing
-func StartConsumption(
-	brokers []string,
+// NewConsumer creates the consumer group client, messages are handled once Consume runs
+func NewConsumer(
+	clientConfig ClientConfig,
 	topics []string,
 	clientId string,
 	groupId string,
-	username string,
-	password string,
 	handlerFunc func(*sarama.ConsumerMessage) error,
-) error {
-	keepRunning := true
-
+) (*Consumer, error) {
 	// Create Kafka consumer configuration
-	config := createConsumerConfig(username, password)
+	config, err := createConsumerConfig(clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kafka client configuration: %w", err)
+	}
+	config.ClientID = clientId
 
 	// Create the KafkaConsumerGroupHandler to process messages
 	consumer := KafkaConsumerGroupHandler{
@@ -61,65 +74,85 @@ func StartConsumption(
 		handlerFunc: handlerFunc,
 	}
 
-	// Wrap the consumer with OpenTelemetry for tracing
-	wrapped := otelsarama.WrapConsumerGroupHandler(&consumer)
+	client, err := sarama.NewConsumerGroup(clientConfig.Brokers, groupId, config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating consumer client: %w", err)
+	}
 
 	// Create a context to handle cancellation
 	ctx, cancel := context.WithCancel(context.Background())
-	client, err := sarama.NewConsumerGroup(brokers, groupId, config)
-	if err != nil {
-		log.Panicf("error creating consumer client: %v", err)
-	}
+
+	return &Consumer{
+		client: client,
+		topics: topics,
+		group:  &consumer,
+		// Wrap the consumer with OpenTelemetry for tracing
+		handler: otelsarama.WrapConsumerGroupHandler(&consumer),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// Consume blocks until Stop is called, SIGUSR1 pauses or resumes consumption meanwhile
+func (c *Consumer) Consume() error {
+	defer close(c.done)
 
 	consumptionIsPaused := false
-	wg := &sync.WaitGroup{}
-	wg.Add(1)
 
-	// Start the Kafka consumption in a separate goroutine
+	// Handle signals for pausing and resuming
+	sigusr1 := make(chan os.Signal, 1)
+	signal.Notify(sigusr1, syscall.SIGUSR1)
+	defer signal.Stop(sigusr1)
+
 	go func() {
-		defer wg.Done()
 		for {
-			// Start consuming messages
-			if err := client.Consume(ctx, topics, wrapped); err != nil {
-				log.Panicf("error initiating consumption: %v", err)
-			}
-
-			// Exit if the context is done
-			if ctx.Err() != nil {
+			select {
+			case <-c.ctx.Done():
 				return
+			case <-sigusr1:
+				toggleConsumptionFlow(c.client, &consumptionIsPaused)
 			}
-
-			consumer.ready = make(chan bool)
 		}
 	}()
 
-	// Wait for the consumer to be ready
-	<-consumer.ready
 	log.Println("Sarama consumer up and running!...")
 
-	// Handle signals for pausing, resuming, and termination
-	sigusr1 := make(chan os.Signal, 1)
-	signal.Notify(sigusr1, syscall.SIGUSR1)
+	for {
+		// Consume returns at every rebalance, join the group again until stopped
+		if err := c.client.Consume(c.ctx, c.topics, c.handler); err != nil {
+			return fmt.Errorf("error initiating consumption: %w", err)
+		}
 
-	sigterm := make(chan os.Signal, 1)
-	signal.Notify(sigterm, syscall.SIGINT, syscall.SIGTERM)
-
-	for keepRunning {
-		select {
-		case <-ctx.Done():
-			keepRunning = false
-		case <-sigterm:
-			keepRunning = false
-		case <-sigusr1:
-			toggleConsumptionFlow(client, &consumptionIsPaused)
+		// Exit if the context is done
+		if c.ctx.Err() != nil {
+			return nil
 		}
+
+		c.group.ready = make(chan bool)
+	}
+}
+
+// CheckJoined fails while the consumer is outside its group, e.g. during a rebalance
+func (c *Consumer) CheckJoined(ctx context.Context) error {
+	if !c.group.joined.Load() {
+		return ErrConsumerNotJoined
 	}
+	return nil
+}
 
-	cancel()
-	wg.Wait()
+// Stop lets the message being handled finish, leaves the group and closes the client
+func (c *Consumer) Stop(ctx context.Context) error {
+	c.cancel()
+
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 
-	if err = client.Close(); err != nil {
-		log.Panicf("error closing the client: %v", err)
+	if err := c.client.Close(); err != nil {
+		return fmt.Errorf("error closing the client: %w", err)
 	}
 
 	return nil
@@ -1,19 +1,25 @@
 package kafka
 
 import (
+	"sync/atomic"
+
 	"github.com/IBM/sarama"
 )
 
 type KafkaConsumerGroupHandler struct {
 	ready       chan bool
 	handlerFunc func(*sarama.ConsumerMessage) error
+	// joined is set between Setup and Cleanup, i.e. while partitions are assigned to this member
+	joined atomic.Bool
 }
 
 func (cg *KafkaConsumerGroupHandler) Setup(sarama.ConsumerGroupSession) error {
+	cg.joined.Store(true)
 	return nil
 }
 
 func (cg *KafkaConsumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	cg.joined.Store(false)
 	close(cg.ready)
 	return nil
 }
@@ -0,0 +1,82 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// MetadataChecker checks that the brokers answer metadata requests, its client is kept open between checks
+type MetadataChecker struct {
+	brokers []string
+	config  *sarama.Config
+	mu      sync.Mutex
+	client  sarama.Client
+}
+
+func NewMetadataChecker(client ClientConfig) (*MetadataChecker, error) {
+	config, err := createConsumerConfig(client)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kafka client configuration: %w", err)
+	}
+	// Fail the check instead of retrying past its timeout
+	config.Metadata.Retry.Max = 0
+
+	return &MetadataChecker{
+		brokers: client.Brokers,
+		config:  config,
+	}, nil
+}
+
+func (m *MetadataChecker) Check(ctx context.Context) error {
+	done := make(chan error, 1)
+
+	go func() {
+		done <- m.refresh()
+	}()
+
+	// sarama does not take a context, a check that outlives ctx finishes in the background
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *MetadataChecker) refresh() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.client == nil {
+		client, err := sarama.NewClient(m.brokers, m.config)
+		if err != nil {
+			return fmt.Errorf("failed to reach kafka brokers: %w", err)
+		}
+		m.client = client
+	}
+
+	if err := m.client.RefreshMetadata(); err != nil {
+		return fmt.Errorf("failed to refresh kafka metadata: %w", err)
+	}
+
+	if len(m.client.Brokers()) == 0 {
+		return errors.New("kafka metadata lists no brokers")
+	}
+
+	return nil
+}
+
+func (m *MetadataChecker) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.client == nil {
+		return nil
+	}
+
+	return m.client.Close()
+}
@@ -2,25 +2,72 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/IBM/sarama"
+	exception "github.com/demo/rolldice/pkg/exceptions"
+	"github.com/demo/rolldice/pkg/idgen"
 	"github.com/dnwe/otelsarama"
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 )
 
+const EventIDHeader = "event_id"
+
+var (
+	ErrTransactionsDisabled = exception.New("kafka.transactions_disabled", exception.CategoryUnavailable, "batch publishing requires KAFKA_TRANSACTIONAL_ID").WithRetryable(false)
+	ErrPublishFailed        = exception.New("kafka.publish_failed", exception.CategoryUnavailable, "the event could not be published, retry later")
+)
+
+// Message is published with ID as its event ID header, a new ID is generated when it is empty.
+// Republishing with the same ID lets consumers drop the duplicate
+type Message struct {
+	ID    string
+	Key   string
+	Value string
+	// Type, ContentType and Time are CloudEvents attributes, they default to the topic, application/json and now
+	Type        string
+	ContentType string
+	Time        time.Time
+	// TraceContext is the W3C trace context the event was created in, for the CloudEvents distributed tracing
+	// extension. The context the message is published in is used when it is nil
+	TraceContext map[string]string
+}
+
 type KafkaProducer struct {
-	producer sarama.SyncProducer
-	logger   *logrus.Logger
-	tracer   trace.Tracer
+	producer    sarama.SyncProducer
+	txnProducer sarama.SyncProducer
+	// txnMu is held by the open Transaction
+	txnMu           sync.Mutex
+	transactionalID string
+	async           *asyncProducer
+	// cloudEventsMode is empty when messages are published without an envelope
+	cloudEventsMode   CloudEventsMode
+	cloudEventsSource string
+	idGenerator       idgen.Generator
+	logger            *logrus.Logger
+	tracer            trace.Tracer
 }
 
-func NewKafkaProducer(brokers []string, username, password string, logger *logrus.Logger, tracer trace.Tracer) (*KafkaProducer, error) {
-	config := createProducerConfig(username, password)
+func NewKafkaProducer(client ClientConfig, idGenerator idgen.Generator, logger *logrus.Logger, tracer trace.Tracer, opts ...ProducerOption) (*KafkaProducer, error) {
+	options := &producerOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	config, err := createProducerConfig(client, options.idempotent)
+	if err != nil {
+		logger.WithError(err).Error("Invalid Kafka client configuration")
+		return nil, fmt.Errorf("invalid Kafka client configuration: %w", err)
+	}
 
-	producer, err := sarama.NewSyncProducer(brokers, config)
+	producer, err := sarama.NewSyncProducer(client.Brokers, config)
 	if err != nil {
 		logger.WithError(err).Error("Failed to create Kafka SyncProducer")
 		return nil, fmt.Errorf("failed to create Kafka SyncProducer: %w", err)
@@ -28,51 +75,190 @@ func NewKafkaProducer(brokers []string, username, password string, logger *logru
 
 	wrappedProducer := otelsarama.WrapSyncProducer(config, producer)
 
-	return &KafkaProducer{
-		producer: wrappedProducer,
-		logger:   logger,
-		tracer:   tracer,
-	}, nil
+	kafkaProducer := &KafkaProducer{
+		producer:          wrappedProducer,
+		cloudEventsMode:   options.cloudEvents,
+		cloudEventsSource: options.source,
+		idGenerator:       idGenerator,
+		logger:            logger,
+		tracer:            tracer,
+	}
+
+	if options.transactionalID != "" {
+		// A transactional producer must send every message inside a transaction, so single
+		// publishes keep the plain producer and only transactions pay for their round-trips
+		txnConfig, err := createTransactionalProducerConfig(client, options.transactionalID)
+		if err != nil {
+			producer.Close()
+			return nil, fmt.Errorf("invalid Kafka client configuration: %w", err)
+		}
+
+		txnProducer, err := sarama.NewSyncProducer(client.Brokers, txnConfig)
+		if err != nil {
+			producer.Close()
+			logger.WithError(err).Error("Failed to create transactional Kafka SyncProducer")
+			return nil, fmt.Errorf("failed to create transactional Kafka SyncProducer: %w", err)
+		}
+
+		kafkaProducer.txnProducer = otelsarama.WrapSyncProducer(txnConfig, txnProducer)
+		kafkaProducer.transactionalID = options.transactionalID
+	}
+
+	if options.async != nil {
+		async := options.async.withDefaults()
+
+		asyncConfig, err := newAsyncProducerConfig(client, async, options.idempotent)
+		if err == nil {
+			kafkaProducer.async, err = newAsyncProducer(client.Brokers, asyncConfig, async.MaxInFlight, kafkaProducer.logDelivery)
+		}
+		if err != nil {
+			kafkaProducer.Close()
+			logger.WithError(err).Error("Failed to create Kafka AsyncProducer")
+			return nil, fmt.Errorf("failed to create Kafka AsyncProducer: %w", err)
+		}
+	}
+
+	return kafkaProducer, nil
 }
 
-func createProducerConfig(username, password string) *sarama.Config {
-	config := sarama.NewConfig()
+// createProducerConfig retries failed sends, without idempotence a retry after a lost acknowledgment
+// writes the message twice
+func createProducerConfig(client ClientConfig, idempotent bool) (*sarama.Config, error) {
+	config, err := client.newSaramaConfig()
+	if err != nil {
+		return nil, err
+	}
+
 	config.Producer.RequiredAcks = sarama.WaitForAll
 	config.Producer.Retry.Max = 5
 	config.Producer.Return.Successes = true
 
-	config.Net.SASL.Enable = true
-	config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
-	config.Net.SASL.User = username
-	config.Net.SASL.Password = password
-	config.Net.TLS.Enable = true
+	if idempotent {
+		// The broker drops retried batches by producer ID and sequence number, which needs
+		// requests to be sent in order
+		config.Version = sarama.V2_5_0_0
+		config.Producer.Idempotent = true
+		config.Net.MaxOpenRequests = 1
+	}
 
-	return config
+	return config, nil
+}
+
+func createTransactionalProducerConfig(client ClientConfig, transactionalID string) (*sarama.Config, error) {
+	config, err := createProducerConfig(client, true)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Producer.Transaction.ID = transactionalID
+
+	return config, nil
 }
 
 func (p *KafkaProducer) Publish(ctx context.Context, topic, value, key string) error {
+	return p.PublishMessage(ctx, topic, Message{Key: key, Value: value})
+}
+
+func (p *KafkaProducer) PublishMessage(ctx context.Context, topic string, message Message) error {
 	_, span := p.tracer.Start(ctx, "publish to kafka")
 	defer span.End()
 
-	producerMessage := &sarama.ProducerMessage{
-		Topic: topic,
-		Key:   sarama.StringEncoder(key),
-		Value: sarama.StringEncoder(value),
+	producerMessage := p.newMessage(ctx, topic, message)
+
+	partition, offset, err := p.producer.SendMessage(producerMessage)
+	if err != nil {
+		p.logError(ctx, topic, message.Key, message.Value, err)
+		return ErrPublishFailed.Wrap(fmt.Errorf("failed to publish message to Kafka: %w", err))
 	}
 
-	otel.GetTextMapPropagator().Inject(ctx, otelsarama.NewProducerMessageCarrier(producerMessage))
+	p.logSuccess(ctx, topic, message.Key, message.Value, partition, offset)
 
-	partition, offset, err := p.producer.SendMessage(producerMessage)
+	return nil
+}
+
+// Transactional tells whether BeginTxn and PublishBatch are enabled
+func (p *KafkaProducer) Transactional() bool {
+	return p.txnProducer != nil
+}
+
+// PublishBatch publishes all messages in one Kafka transaction, consumers reading committed
+// messages see either all of them or none
+func (p *KafkaProducer) PublishBatch(ctx context.Context, topic string, messages []Message) error {
+	ctx, span := p.tracer.Start(ctx, "publish batch to kafka")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("messaging.batch.message_count", len(messages)))
+
+	txn, err := p.BeginTxn(ctx)
 	if err != nil {
-		p.logError(ctx, topic, key, value, err)
-		return fmt.Errorf("failed to publish message to Kafka: %w", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
 	}
 
-	p.logSuccess(ctx, topic, key, value, partition, offset)
+	if err := txn.Publish(topic, messages...); err != nil {
+		// AbortTxn logs its own failure, the publish error is the one to report
+		txn.AbortTxn()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	if err := txn.CommitTxn(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	p.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"topic": topic,
+		"count": len(messages),
+	}).Info("Message batch published to Kafka topic")
 
 	return nil
 }
 
+// Close flushes and closes the underlying producers, the KafkaProducer cannot be used afterwards
+func (p *KafkaProducer) Close() error {
+	var err error
+	if p.async != nil {
+		// Flushes the buffered messages first, their deliveries complete before Close returns
+		err = p.async.close()
+	}
+
+	err = errors.Join(err, p.producer.Close())
+
+	if p.txnProducer != nil {
+		err = errors.Join(err, p.txnProducer.Close())
+	}
+
+	return err
+}
+
+func (p *KafkaProducer) newMessage(ctx context.Context, topic string, message Message) *sarama.ProducerMessage {
+	id := message.ID
+	if id == "" {
+		id = p.idGenerator.NewID()
+	}
+
+	producerMessage := &sarama.ProducerMessage{
+		Topic: topic,
+		Key:   sarama.StringEncoder(message.Key),
+		Value: sarama.StringEncoder(message.Value),
+		Headers: []sarama.RecordHeader{
+			{Key: []byte(EventIDHeader), Value: []byte(id)},
+		},
+	}
+
+	if p.cloudEventsMode != "" {
+		p.cloudEvent(ctx, producerMessage, id, message)
+	}
+
+	otel.GetTextMapPropagator().Inject(ctx, otelsarama.NewProducerMessageCarrier(producerMessage))
+
+	return producerMessage
+}
+
 func (p *KafkaProducer) logSuccess(ctx context.Context, topic, key, value string, partition int32, offset int64) {
 	p.logger.WithContext(ctx).WithFields(logrus.Fields{
 		"topic":     topic,
@@ -0,0 +1,40 @@
+package kafka
+
+type producerOptions struct {
+	transactionalID string
+	idempotent      bool
+	async           *AsyncConfig
+	cloudEvents     CloudEventsMode
+	source          string
+}
+
+type ProducerOption func(*producerOptions)
+
+// WithTransactionalID enables BeginTxn and PublishBatch with a transactional producer, the ID must be unique per replica
+func WithTransactionalID(id string) ProducerOption {
+	return func(o *producerOptions) {
+		o.transactionalID = id
+	}
+}
+
+// WithIdempotence lets the broker drop the duplicates retries write, transactions are always idempotent
+func WithIdempotence() ProducerOption {
+	return func(o *producerOptions) {
+		o.idempotent = true
+	}
+}
+
+// WithAsync adds an asynchronous producer that batches messages given to PublishAsync, Publish stays synchronous
+func WithAsync(config AsyncConfig) ProducerOption {
+	return func(o *producerOptions) {
+		o.async = &config
+	}
+}
+
+// WithCloudEvents publishes every message as a CloudEvent of source, in binary or structured mode
+func WithCloudEvents(mode CloudEventsMode, source string) ProducerOption {
+	return func(o *producerOptions) {
+		o.cloudEvents = mode
+		o.source = source
+	}
+}
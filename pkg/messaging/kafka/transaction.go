@@ -0,0 +1,187 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrTransactionDone = errors.New("kafka transaction already committed or aborted")
+
+// Transaction publishes messages and commits consumed offsets atomically, consumers reading committed messages
+// see either all of it or none. It holds the transactional producer until it is committed or aborted,
+// its span covers the transaction from BeginTxn to CommitTxn or AbortTxn
+type Transaction struct {
+	producer *KafkaProducer
+	ctx      context.Context
+	span     trace.Span
+	messages int
+	done     bool
+}
+
+// BeginTxn waits for the transaction in progress to end, only one can be open per transactional producer
+func (p *KafkaProducer) BeginTxn(ctx context.Context) (*Transaction, error) {
+	if p.txnProducer == nil {
+		return nil, ErrTransactionsDisabled
+	}
+
+	p.txnMu.Lock()
+
+	ctx, span := p.tracer.Start(ctx, "kafka transaction", trace.WithAttributes(
+		semconv.MessagingSystemKafka,
+		attribute.String("messaging.kafka.transactional_id", p.transactionalID),
+	))
+
+	if err := p.txnProducer.BeginTxn(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		p.txnMu.Unlock()
+		return nil, ErrPublishFailed.Wrap(fmt.Errorf("failed to begin Kafka transaction: %w", err))
+	}
+
+	return &Transaction{producer: p, ctx: ctx, span: span}, nil
+}
+
+// Context carries the span of the transaction
+func (t *Transaction) Context() context.Context {
+	return t.ctx
+}
+
+// Publish sends messages as part of the transaction, a transaction whose Publish failed can only be aborted
+func (t *Transaction) Publish(topic string, messages ...Message) error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	producerMessages := make([]*sarama.ProducerMessage, len(messages))
+	for i, message := range messages {
+		producerMessages[i] = t.producer.newMessage(t.ctx, topic, message)
+	}
+
+	if err := t.producer.txnProducer.SendMessages(producerMessages); err != nil {
+		t.span.RecordError(err)
+		t.span.SetStatus(codes.Error, err.Error())
+		t.producer.logger.WithContext(t.ctx).WithField("topic", topic).WithError(err).Error("Failed to publish messages in Kafka transaction")
+		return ErrPublishFailed.Wrap(fmt.Errorf("failed to publish messages in Kafka transaction: %w", err))
+	}
+
+	t.messages += len(messages)
+
+	return nil
+}
+
+// AddOffsetsToTxn commits the offsets of groupID with the transaction, so the messages a read-process-write
+// pipeline consumed are marked only if what it produced from them is committed. The offsets are the next ones
+// to read, i.e. the offset of the last processed message plus one
+func (t *Transaction) AddOffsetsToTxn(offsets map[string][]*sarama.PartitionOffsetMetadata, groupID string) error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	if err := t.producer.txnProducer.AddOffsetsToTxn(offsets, groupID); err != nil {
+		t.span.RecordError(err)
+		t.span.SetStatus(codes.Error, err.Error())
+		return ErrPublishFailed.Wrap(fmt.Errorf("failed to add offsets of %s to Kafka transaction: %w", groupID, err))
+	}
+
+	t.span.SetAttributes(semconv.MessagingKafkaConsumerGroup(groupID))
+
+	return nil
+}
+
+// AddMessageToTxn is AddOffsetsToTxn for the offset following message
+func (t *Transaction) AddMessageToTxn(message *sarama.ConsumerMessage, groupID string) error {
+	offsets := map[string][]*sarama.PartitionOffsetMetadata{
+		message.Topic: {{Partition: message.Partition, Offset: message.Offset + 1}},
+	}
+
+	return t.AddOffsetsToTxn(offsets, groupID)
+}
+
+// CommitTxn aborts the transaction when the commit fails with an abortable error, the transaction ends either way
+func (t *Transaction) CommitTxn() error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	_, span := t.producer.tracer.Start(t.ctx, "commit kafka transaction")
+
+	err := t.producer.txnProducer.CommitTxn()
+	if err == nil {
+		span.End()
+		t.end("committed")
+		return nil
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.End()
+
+	t.span.SetStatus(codes.Error, err.Error())
+
+	outcome := "failed"
+	if t.producer.txnProducer.TxnStatus()&sarama.ProducerTxnFlagAbortableError != 0 && t.abort(err) == nil {
+		outcome = "aborted"
+	}
+	t.end(outcome)
+
+	return ErrPublishFailed.Wrap(fmt.Errorf("failed to commit Kafka transaction: %w", err))
+}
+
+// AbortTxn discards the messages and offsets of the transaction
+func (t *Transaction) AbortTxn() error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	if err := t.abort(nil); err != nil {
+		t.span.SetStatus(codes.Error, err.Error())
+		t.end("failed")
+		return err
+	}
+
+	t.end("aborted")
+
+	return nil
+}
+
+func (t *Transaction) abort(cause error) error {
+	_, span := t.producer.tracer.Start(t.ctx, "abort kafka transaction")
+	defer span.End()
+
+	logger := t.producer.logger.WithContext(t.ctx).WithField("transactional_id", t.producer.transactionalID)
+
+	if err := t.producer.txnProducer.AbortTxn(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		logger.WithError(err).Error("Failed to abort Kafka transaction")
+		return ErrPublishFailed.Wrap(fmt.Errorf("failed to abort Kafka transaction: %w", err))
+	}
+
+	if cause != nil {
+		logger = logger.WithError(cause)
+	}
+	logger.Warn("Kafka transaction aborted")
+
+	return nil
+}
+
+// end releases the transactional producer, outcome is committed, aborted or failed when the producer could not abort
+func (t *Transaction) end(outcome string) {
+	t.done = true
+
+	t.span.SetAttributes(
+		attribute.String("messaging.kafka.transaction.outcome", outcome),
+		attribute.Int("messaging.batch.message_count", t.messages),
+	)
+	t.span.End()
+
+	t.producer.txnMu.Unlock()
+}
@@ -0,0 +1,102 @@
+package serde
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hamba/avro/v2"
+)
+
+// AvroCodec writes values with avro struct tags, values of an older writer schema are resolved into the codec's schema
+type AvroCodec struct {
+	definition string
+	schema     avro.Schema
+	mu         sync.Mutex
+	resolved   map[int]avro.Schema
+}
+
+func NewAvroCodec(definition string) (*AvroCodec, error) {
+	schema, err := parseAvroSchema(definition)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AvroCodec{
+		definition: definition,
+		schema:     schema,
+		resolved:   map[int]avro.Schema{},
+	}, nil
+}
+
+// parseAvroSchema parses with its own cache, the default one would confuse two versions of the same record name
+func parseAvroSchema(definition string) (avro.Schema, error) {
+	schema, err := avro.ParseWithCache(definition, "", &avro.SchemaCache{})
+	if err != nil {
+		return nil, fmt.Errorf("invalid avro schema: %w", err)
+	}
+	return schema, nil
+}
+
+func (c *AvroCodec) Format() Format {
+	return FormatAvro
+}
+
+func (c *AvroCodec) Schema() string {
+	return c.definition
+}
+
+func (c *AvroCodec) Marshal(v any) ([]byte, error) {
+	return avro.Marshal(c.schema, v)
+}
+
+func (c *AvroCodec) Unmarshal(writer Schema, data []byte, v any) error {
+	schema, err := c.readerFor(writer)
+	if err != nil {
+		return err
+	}
+
+	return avro.Unmarshal(schema, data, v)
+}
+
+// readerFor resolves the codec's schema against the writer schema once per schema ID
+func (c *AvroCodec) readerFor(writer Schema) (avro.Schema, error) {
+	if writer.Definition == c.definition {
+		return c.schema, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if schema, ok := c.resolved[writer.ID]; ok {
+		return schema, nil
+	}
+
+	writerSchema, err := parseAvroSchema(writer.Definition)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := avro.NewSchemaCompatibility().Resolve(c.schema, writerSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve avro schema %d: %w", writer.ID, err)
+	}
+
+	c.resolved[writer.ID] = schema
+
+	return schema, nil
+}
+
+// checkAvroCompatibility applies the avro schema resolution rules, e.g. a field added without a default is incompatible
+func checkAvroCompatibility(reader, writer string) error {
+	readerSchema, err := parseAvroSchema(reader)
+	if err != nil {
+		return err
+	}
+
+	writerSchema, err := parseAvroSchema(writer)
+	if err != nil {
+		return err
+	}
+
+	return avro.NewSchemaCompatibility().Compatible(readerSchema, writerSchema)
+}
@@ -0,0 +1,166 @@
+package serde
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// JSONSchemaCodec writes values as JSON and refuses the ones its schema does not validate
+type JSONSchemaCodec struct {
+	definition string
+	schema     *jsonschema.Schema
+}
+
+func NewJSONSchemaCodec(definition string) (*JSONSchemaCodec, error) {
+	schema, err := jsonschema.CompileString("schema.json", definition)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	return &JSONSchemaCodec{definition, schema}, nil
+}
+
+func (c *JSONSchemaCodec) Format() Format {
+	return FormatJSONSchema
+}
+
+func (c *JSONSchemaCodec) Schema() string {
+	return c.definition
+}
+
+func (c *JSONSchemaCodec) Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	if err := c.schema.Validate(document); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Unmarshal ignores the writer schema, JSON carries its field names
+func (c *JSONSchemaCodec) Unmarshal(_ Schema, data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// checkJSONSchemaCompatibility compares the keywords that decide which documents are valid: type, required,
+// properties, additionalProperties, items and enum. Composition keywords and references are not followed
+func checkJSONSchemaCompatibility(reader, writer string) error {
+	var readerSchema, writerSchema map[string]any
+
+	if err := json.Unmarshal([]byte(reader), &readerSchema); err != nil {
+		return fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(writer), &writerSchema); err != nil {
+		return fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	return compareJSONSchemas(readerSchema, writerSchema, "#")
+}
+
+func compareJSONSchemas(reader, writer map[string]any, path string) error {
+	readerTypes, writerTypes := jsonSchemaTypes(reader), jsonSchemaTypes(writer)
+	if len(readerTypes) > 0 {
+		if len(writerTypes) == 0 {
+			return fmt.Errorf("%s: type restricted to %v", path, readerTypes)
+		}
+		for _, writerType := range writerTypes {
+			if !slices.Contains(readerTypes, writerType) && !(writerType == "integer" && slices.Contains(readerTypes, "number")) {
+				return fmt.Errorf("%s: type %s no longer allowed", path, writerType)
+			}
+		}
+	}
+
+	writerRequired := jsonSchemaStrings(writer["required"])
+	for _, property := range jsonSchemaStrings(reader["required"]) {
+		if !slices.Contains(writerRequired, property) {
+			return fmt.Errorf("%s: property %s became required", path, property)
+		}
+	}
+
+	readerClosed := reader["additionalProperties"] == false
+	if readerClosed && writer["additionalProperties"] != false {
+		return fmt.Errorf("%s: additional properties no longer allowed", path)
+	}
+
+	readerProperties, _ := reader["properties"].(map[string]any)
+	writerProperties, _ := writer["properties"].(map[string]any)
+
+	for name, writerProperty := range writerProperties {
+		readerProperty, ok := readerProperties[name]
+		if !ok {
+			if readerClosed {
+				return fmt.Errorf("%s: property %s removed while additional properties are not allowed", path, name)
+			}
+			continue
+		}
+
+		readerMap, readerOk := readerProperty.(map[string]any)
+		writerMap, writerOk := writerProperty.(map[string]any)
+		if readerOk && writerOk {
+			if err := compareJSONSchemas(readerMap, writerMap, path+"/properties/"+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	readerItems, readerOk := reader["items"].(map[string]any)
+	writerItems, writerOk := writer["items"].(map[string]any)
+	if readerOk && writerOk {
+		if err := compareJSONSchemas(readerItems, writerItems, path+"/items"); err != nil {
+			return err
+		}
+	}
+
+	if readerEnum, ok := reader["enum"].([]any); ok {
+		writerEnum, ok := writer["enum"].([]any)
+		if !ok {
+			return fmt.Errorf("%s: values restricted to an enum", path)
+		}
+		for _, value := range writerEnum {
+			if !slices.ContainsFunc(readerEnum, func(readerValue any) bool { return reflect.DeepEqual(readerValue, value) }) {
+				return fmt.Errorf("%s: enum value %v removed", path, value)
+			}
+		}
+	}
+
+	return nil
+}
+
+// jsonSchemaTypes reads type as a single name or a list of names
+func jsonSchemaTypes(schema map[string]any) []string {
+	if name, ok := schema["type"].(string); ok {
+		return []string{name}
+	}
+	return jsonSchemaStrings(schema["type"])
+}
+
+func jsonSchemaStrings(value any) []string {
+	values, _ := value.([]any)
+
+	strings := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			strings = append(strings, s)
+		}
+	}
+
+	return strings
+}
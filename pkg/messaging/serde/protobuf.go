@@ -0,0 +1,205 @@
+package serde
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ProtobufCodec writes messages of one type. Its schema is the JSON form of a FileDescriptorSet holding the file
+// of the message and its imports, the message itself is named by the message indexes of the payload
+type ProtobufCodec struct {
+	message    protoreflect.MessageDescriptor
+	definition string
+	indexes    []byte
+}
+
+func NewProtobufCodec(message proto.Message) (*ProtobufCodec, error) {
+	descriptor := message.ProtoReflect().Descriptor()
+
+	set := &descriptorpb.FileDescriptorSet{}
+	addFileDescriptor(set, descriptor.ParentFile(), map[string]bool{})
+
+	definition, err := protojson.Marshal(set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal protobuf schema: %w", err)
+	}
+
+	return &ProtobufCodec{
+		message:    descriptor,
+		definition: string(definition),
+		indexes:    messageIndexes(descriptor),
+	}, nil
+}
+
+// addFileDescriptor adds the imports of file before it, the order protodesc.NewFiles resolves them in
+func addFileDescriptor(set *descriptorpb.FileDescriptorSet, file protoreflect.FileDescriptor, added map[string]bool) {
+	if added[file.Path()] {
+		return
+	}
+	added[file.Path()] = true
+
+	imports := file.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		addFileDescriptor(set, imports.Get(i).FileDescriptor, added)
+	}
+
+	set.File = append(set.File, protodesc.ToFileDescriptorProto(file))
+}
+
+// messageIndexes locates the message in its file as the registry wire format does: the zigzag varint count of
+// indexes then each index, with the first top-level message shortened to a single 0
+func messageIndexes(message protoreflect.MessageDescriptor) []byte {
+	path := []int{}
+	for descriptor := protoreflect.Descriptor(message); descriptor != nil; descriptor = descriptor.Parent() {
+		if _, ok := descriptor.(protoreflect.FileDescriptor); ok {
+			break
+		}
+		path = append([]int{descriptor.Index()}, path...)
+	}
+
+	if len(path) == 1 && path[0] == 0 {
+		return []byte{0}
+	}
+
+	indexes := binary.AppendVarint(nil, int64(len(path)))
+	for _, index := range path {
+		indexes = binary.AppendVarint(indexes, int64(index))
+	}
+	return indexes
+}
+
+func (c *ProtobufCodec) Format() Format {
+	return FormatProtobuf
+}
+
+func (c *ProtobufCodec) Schema() string {
+	return c.definition
+}
+
+func (c *ProtobufCodec) Marshal(v any) ([]byte, error) {
+	message, ok := v.(proto.Message)
+	if !ok || message.ProtoReflect().Descriptor().FullName() != c.message.FullName() {
+		return nil, fmt.Errorf("expected a %s message, got %T", c.message.FullName(), v)
+	}
+
+	payload, err := proto.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(slices.Clone(c.indexes), payload...), nil
+}
+
+// Unmarshal decodes into v whatever message the indexes name, fields are matched by number so older writers are read
+func (c *ProtobufCodec) Unmarshal(_ Schema, data []byte, v any) error {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("expected a proto.Message, got %T", v)
+	}
+
+	count, n := binary.Varint(data)
+	if n <= 0 || count < 0 {
+		return errors.New("invalid protobuf message indexes")
+	}
+	data = data[n:]
+
+	for i := int64(0); i < count; i++ {
+		if _, n = binary.Varint(data); n <= 0 {
+			return errors.New("invalid protobuf message indexes")
+		}
+		data = data[n:]
+	}
+
+	return proto.Unmarshal(data, message)
+}
+
+// checkProtobufCompatibility fails when a field number of a writer message is read with another type or cardinality,
+// fields added or removed are compatible since proto3 fields are optional
+func checkProtobufCompatibility(reader, writer string) error {
+	readerFiles, err := parseProtobufSchema(reader)
+	if err != nil {
+		return err
+	}
+
+	writerFiles, err := parseProtobufSchema(writer)
+	if err != nil {
+		return err
+	}
+
+	var incompatible error
+	readerFiles.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		messages := file.Messages()
+		for i := 0; i < messages.Len() && incompatible == nil; i++ {
+			descriptor, err := writerFiles.FindDescriptorByName(messages.Get(i).FullName())
+			if err != nil {
+				// A message the writer does not have is new
+				continue
+			}
+			if writerMessage, ok := descriptor.(protoreflect.MessageDescriptor); ok {
+				incompatible = compareProtobufMessages(messages.Get(i), writerMessage, map[protoreflect.FullName]bool{})
+			}
+		}
+		return incompatible == nil
+	})
+
+	return incompatible
+}
+
+func parseProtobufSchema(definition string) (*protoregistry.Files, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := protojson.Unmarshal([]byte(definition), set); err != nil {
+		return nil, fmt.Errorf("invalid protobuf schema: %w", err)
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid protobuf schema: %w", err)
+	}
+
+	return files, nil
+}
+
+func compareProtobufMessages(reader, writer protoreflect.MessageDescriptor, visited map[protoreflect.FullName]bool) error {
+	if visited[reader.FullName()] {
+		return nil
+	}
+	visited[reader.FullName()] = true
+
+	writerFields := writer.Fields()
+	for i := 0; i < writerFields.Len(); i++ {
+		writerField := writerFields.Get(i)
+
+		readerField := reader.Fields().ByNumber(writerField.Number())
+		if readerField == nil {
+			continue
+		}
+
+		if readerField.Kind() != writerField.Kind() {
+			return fmt.Errorf("field %d of %s changed from %s to %s", writerField.Number(), reader.FullName(), writerField.Kind(), readerField.Kind())
+		}
+
+		if readerField.Cardinality() != writerField.Cardinality() || readerField.IsMap() != writerField.IsMap() {
+			return fmt.Errorf("field %d of %s changed cardinality", writerField.Number(), reader.FullName())
+		}
+
+		if readerField.Message() != nil {
+			if readerField.Message().FullName() != writerField.Message().FullName() {
+				return fmt.Errorf("field %d of %s changed from %s to %s", writerField.Number(), reader.FullName(), writerField.Message().FullName(), readerField.Message().FullName())
+			}
+			if err := compareProtobufMessages(readerField.Message(), writerField.Message(), visited); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package serde
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Registry stores the schema versions of every subject, a new version must be backward compatible with the latest one
+type Registry interface {
+	// Register returns the existing version when definition is already registered under subject
+	Register(ctx context.Context, subject string, format Format, definition string) (Schema, error)
+	Latest(ctx context.Context, subject string) (Schema, error)
+	ByID(ctx context.Context, id int) (Schema, error)
+}
+
+// FileRegistry is a local stand-in for a schema registry, processes sharing the file share the schemas.
+// The file is read again before registering and when an ID is unknown, so schemas registered by other processes are seen.
+// Registering holds a lock on the file path with a .lock suffix, so two processes never assign the same ID
+type FileRegistry struct {
+	path    string
+	mu      sync.Mutex
+	schemas []Schema
+}
+
+type registryFile struct {
+	Schemas []Schema `json:"schemas"`
+}
+
+func NewFileRegistry(path string) (*FileRegistry, error) {
+	registry := &FileRegistry{path: path}
+
+	if err := registry.load(); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+func (r *FileRegistry) Register(_ context.Context, subject string, format Format, definition string) (Schema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unlock, err := lockFile(r.path + ".lock")
+	if err != nil {
+		return Schema{}, err
+	}
+	defer unlock()
+
+	if err := r.load(); err != nil {
+		return Schema{}, err
+	}
+
+	latest, found := r.latest(subject)
+
+	for _, schema := range r.schemas {
+		if schema.Subject == subject && schema.Format == format && strings.TrimSpace(schema.Definition) == strings.TrimSpace(definition) {
+			return schema, nil
+		}
+	}
+
+	if found {
+		if err := CheckCompatibility(format, definition, latest.Format, latest.Definition); err != nil {
+			return Schema{}, fmt.Errorf("version %d of %s: %w", latest.Version, subject, err)
+		}
+	}
+
+	schema := Schema{
+		ID:         1,
+		Subject:    subject,
+		Version:    latest.Version + 1,
+		Format:     format,
+		Definition: definition,
+	}
+	for _, registered := range r.schemas {
+		schema.ID = max(schema.ID, registered.ID+1)
+	}
+
+	r.schemas = append(r.schemas, schema)
+
+	if err := r.save(); err != nil {
+		r.schemas = r.schemas[:len(r.schemas)-1]
+		return Schema{}, err
+	}
+
+	return schema, nil
+}
+
+func (r *FileRegistry) Latest(_ context.Context, subject string) (Schema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return Schema{}, err
+	}
+
+	latest, found := r.latest(subject)
+	if !found {
+		return Schema{}, fmt.Errorf("%w: subject %s", ErrSchemaNotFound, subject)
+	}
+
+	return latest, nil
+}
+
+func (r *FileRegistry) ByID(_ context.Context, id int) (Schema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if schema, found := r.byID(id); found {
+		return schema, nil
+	}
+
+	if err := r.load(); err != nil {
+		return Schema{}, err
+	}
+
+	if schema, found := r.byID(id); found {
+		return schema, nil
+	}
+
+	return Schema{}, fmt.Errorf("%w: id %d", ErrSchemaNotFound, id)
+}
+
+func (r *FileRegistry) latest(subject string) (latest Schema, found bool) {
+	for _, schema := range r.schemas {
+		if schema.Subject == subject && schema.Version > latest.Version {
+			latest, found = schema, true
+		}
+	}
+	return latest, found
+}
+
+func (r *FileRegistry) byID(id int) (Schema, bool) {
+	for _, schema := range r.schemas {
+		if schema.ID == id {
+			return schema, true
+		}
+	}
+	return Schema{}, false
+}
+
+func (r *FileRegistry) load() error {
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		r.schemas = nil
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read schema registry: %w", err)
+	}
+
+	var file registryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse schema registry %s: %w", r.path, err)
+	}
+
+	r.schemas = file.Schemas
+
+	return nil
+}
+
+// save replaces the file with a rename, so a process reading it never sees a partial write
+func (r *FileRegistry) save() error {
+	data, err := json.MarshalIndent(registryFile{r.schemas}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema registry: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write schema registry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write schema registry: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write schema registry: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("failed to write schema registry: %w", err)
+	}
+
+	return nil
+}
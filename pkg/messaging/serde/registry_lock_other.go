@@ -0,0 +1,8 @@
+//go:build !unix
+
+package serde
+
+// lockFile does not lock outside unix, processes registering at the same time there can lose an update
+func lockFile(string) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package serde
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lockFile holds an exclusive flock on path until the returned function is called, other processes wait for it
+func lockFile(path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock schema registry: %w", err)
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock schema registry: %w", err)
+	}
+
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,213 @@
+package serde
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Format is the schema type, named as in the Confluent schema registry
+type Format string
+
+const (
+	FormatJSONSchema Format = "JSON"
+	FormatProtobuf   Format = "PROTOBUF"
+	FormatAvro       Format = "AVRO"
+)
+
+// MagicByte starts every serialized value, followed by the big-endian schema ID and the payload
+const MagicByte byte = 0
+
+const headerSize = 5
+
+var (
+	ErrSchemaNotFound     = errors.New("schema not found")
+	ErrIncompatibleSchema = errors.New("incompatible schema")
+	ErrUnframed           = errors.New("value does not start with the schema registry magic byte")
+)
+
+// Schema is a version of a subject, its ID is unique across subjects
+type Schema struct {
+	ID         int    `json:"id"`
+	Subject    string `json:"subject"`
+	Version    int    `json:"version"`
+	Format     Format `json:"format"`
+	Definition string `json:"definition"`
+}
+
+// Codec encodes the values of one schema, it can read values written with the older versions the schema is compatible with
+type Codec interface {
+	Format() Format
+	// Schema is the definition registered for the values the codec writes
+	Schema() string
+	Marshal(v any) ([]byte, error)
+	// Unmarshal reads data written with the writer schema into v
+	Unmarshal(writer Schema, data []byte, v any) error
+}
+
+// IsFramed tells whether data starts with the header of the registry wire format
+func IsFramed(data []byte) bool {
+	return len(data) >= headerSize && data[0] == MagicByte
+}
+
+// Serializer writes values of one subject in the registry wire format
+type Serializer struct {
+	codec  Codec
+	schema Schema
+}
+
+// NewSerializer registers the schema of codec under subject, it fails when the schema is not backward compatible
+// with the latest version so an incompatible change is caught at startup rather than by consumers
+func NewSerializer(ctx context.Context, registry Registry, subject string, codec Codec) (*Serializer, error) {
+	schema, err := registry.Register(ctx, subject, codec.Format(), codec.Schema())
+	if err != nil {
+		return nil, fmt.Errorf("failed to register schema of %s: %w", subject, err)
+	}
+
+	return &Serializer{codec, schema}, nil
+}
+
+func (s *Serializer) Serialize(v any) ([]byte, error) {
+	payload, err := s.codec.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize %s: %w", s.schema.Subject, err)
+	}
+
+	data := make([]byte, headerSize, headerSize+len(payload))
+	data[0] = MagicByte
+	binary.BigEndian.PutUint32(data[1:headerSize], uint32(s.schema.ID))
+
+	return append(data, payload...), nil
+}
+
+func (s *Serializer) Schema() Schema {
+	return s.schema
+}
+
+// ContentType names the format of serialized values, including the schema ID header
+func (s *Serializer) ContentType() string {
+	switch s.codec.Format() {
+	case FormatAvro:
+		return "application/vnd.schemaregistry.v1+avro"
+	case FormatProtobuf:
+		return "application/vnd.schemaregistry.v1+protobuf"
+	default:
+		return "application/vnd.schemaregistry.v1+json"
+	}
+}
+
+// Deserializer reads values of one subject written with any registered schema one of its codecs is compatible with,
+// the codec is picked by the format of the writer schema
+type Deserializer struct {
+	registry Registry
+	subject  string
+	codecs   map[Format]Codec
+	mu       sync.Mutex
+	checked  map[int]error
+}
+
+// NewDeserializer fails when no codec can read values written with the latest schema of subject,
+// a subject with no schema yet is checked once its first value arrives
+func NewDeserializer(ctx context.Context, registry Registry, subject string, codecs ...Codec) (*Deserializer, error) {
+	d := &Deserializer{
+		registry: registry,
+		subject:  subject,
+		codecs:   map[Format]Codec{},
+		checked:  map[int]error{},
+	}
+
+	for _, codec := range codecs {
+		d.codecs[codec.Format()] = codec
+	}
+
+	latest, err := registry.Latest(ctx, subject)
+	if errors.Is(err, ErrSchemaNotFound) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up schema of %s: %w", subject, err)
+	}
+
+	if _, err := d.codecFor(latest); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (d *Deserializer) Deserialize(ctx context.Context, data []byte, v any) error {
+	if !IsFramed(data) {
+		return ErrUnframed
+	}
+
+	id := int(binary.BigEndian.Uint32(data[1:headerSize]))
+
+	writer, err := d.registry.ByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to look up schema %d: %w", id, err)
+	}
+
+	codec, err := d.codecFor(writer)
+	if err != nil {
+		return err
+	}
+
+	if err := codec.Unmarshal(writer, data[headerSize:], v); err != nil {
+		return fmt.Errorf("failed to deserialize %s with schema %d: %w", d.subject, id, err)
+	}
+
+	return nil
+}
+
+// codecFor remembers the compatibility of every writer schema, the checks parse both definitions
+func (d *Deserializer) codecFor(writer Schema) (Codec, error) {
+	codec, ok := d.codecs[writer.Format]
+	if !ok {
+		return nil, fmt.Errorf("no codec reads %s schema %d of %s", writer.Format, writer.ID, writer.Subject)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	err, ok := d.checked[writer.ID]
+	if !ok {
+		err = CheckCompatibility(codec.Format(), codec.Schema(), writer.Format, writer.Definition)
+		if err != nil {
+			err = fmt.Errorf("schema %d of %s cannot be read: %w", writer.ID, writer.Subject, err)
+		}
+		d.checked[writer.ID] = err
+	}
+
+	return codec, err
+}
+
+// CheckCompatibility tells whether values written with the writer schema can be read with the reader schema
+func CheckCompatibility(readerFormat Format, reader string, writerFormat Format, writer string) error {
+	if readerFormat != writerFormat {
+		return fmt.Errorf("%w: format changed from %s to %s", ErrIncompatibleSchema, writerFormat, readerFormat)
+	}
+
+	if reader == writer {
+		return nil
+	}
+
+	var err error
+	switch readerFormat {
+	case FormatJSONSchema:
+		err = checkJSONSchemaCompatibility(reader, writer)
+	case FormatProtobuf:
+		err = checkProtobufCompatibility(reader, writer)
+	case FormatAvro:
+		err = checkAvroCompatibility(reader, writer)
+	default:
+		return fmt.Errorf("unsupported schema format %q", readerFormat)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrIncompatibleSchema, err)
+	}
+
+	return nil
+}
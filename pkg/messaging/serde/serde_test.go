@@ -0,0 +1,252 @@
+package serde
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+const (
+	pointJSONSchema = `{"type": "object", "properties": {"x": {"type": "integer"}, "y": {"type": "integer"}}, "required": ["x"]}`
+	pointAvroSchema = `{"type": "record", "name": "Point", "fields": [{"name": "x", "type": "int"}, {"name": "y", "type": "int", "default": 0}]}`
+)
+
+type point struct {
+	X int `json:"x" avro:"x"`
+	Y int `json:"y" avro:"y"`
+}
+
+func TestSerializerFraming(t *testing.T) {
+	tests := []struct {
+		format     Format
+		definition string
+	}{
+		{FormatJSONSchema, pointJSONSchema},
+		{FormatAvro, pointAvroSchema},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.format), func(t *testing.T) {
+			ctx := context.Background()
+			registry := newFileRegistry(t)
+
+			// Another subject takes the first ID, so the header must carry the schema's own ID
+			if _, err := registry.Register(ctx, "other-value", FormatJSONSchema, `{"type": "string"}`); err != nil {
+				t.Fatal(err)
+			}
+
+			codec := newCodec(t, test.format, test.definition)
+
+			serializer, err := NewSerializer(ctx, registry, "points-value", codec)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			deserializer, err := NewDeserializer(ctx, registry, "points-value", codec)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := serializer.Serialize(point{X: 3, Y: -4})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			payload, err := codec.Marshal(point{X: 3, Y: -4})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if header := []byte{MagicByte, 0, 0, 0, 2}; !bytes.Equal(data[:headerSize], header) {
+				t.Errorf("header = %v, want %v", data[:headerSize], header)
+			}
+			if !bytes.Equal(data[headerSize:], payload) {
+				t.Errorf("payload = %v, want %v", data[headerSize:], payload)
+			}
+
+			var decoded point
+			if err := deserializer.Deserialize(ctx, data, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if decoded != (point{X: 3, Y: -4}) {
+				t.Errorf("round trip = %+v, want %+v", decoded, point{X: 3, Y: -4})
+			}
+		})
+	}
+}
+
+func TestDeserializeRejectsUnknownFrames(t *testing.T) {
+	ctx := context.Background()
+	registry := newFileRegistry(t)
+
+	deserializer, err := NewDeserializer(ctx, registry, "points-value", newCodec(t, FormatJSONSchema, pointJSONSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{"plain JSON", []byte(`{"x": 1}`), ErrUnframed},
+		{"shorter than the header", []byte{MagicByte, 0, 0}, ErrUnframed},
+		{"unknown schema ID", []byte{MagicByte, 0, 0, 0, 9, '{', '}'}, ErrSchemaNotFound},
+	}
+
+	for _, test := range tests {
+		var decoded point
+		if err := deserializer.Deserialize(ctx, test.data, &decoded); !errors.Is(err, test.err) {
+			t.Errorf("%s: error = %v, want %v", test.name, err, test.err)
+		}
+	}
+}
+
+func TestFileRegistryRegister(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "schemas.json")
+
+	registry, err := NewFileRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		subject    string
+		format     Format
+		definition string
+		id         int
+		version    int
+		err        error
+	}{
+		{"first version", "points-value", FormatAvro, pointAvroSchema, 1, 1, nil},
+		{"same definition", "points-value", FormatAvro, "  " + pointAvroSchema + "\n", 1, 1, nil},
+		{"other subject", "other-value", FormatJSONSchema, pointJSONSchema, 2, 1, nil},
+		{"field added with a default", "points-value", FormatAvro, `{"type": "record", "name": "Point", "fields": [{"name": "x", "type": "int"}, {"name": "y", "type": "int", "default": 0}, {"name": "z", "type": "int", "default": 0}]}`, 3, 2, nil},
+		{"field added without a default", "points-value", FormatAvro, `{"type": "record", "name": "Point", "fields": [{"name": "x", "type": "int"}, {"name": "w", "type": "int"}]}`, 0, 0, ErrIncompatibleSchema},
+		{"format changed", "points-value", FormatJSONSchema, pointJSONSchema, 0, 0, ErrIncompatibleSchema},
+	}
+
+	for _, test := range tests {
+		schema, err := registry.Register(ctx, test.subject, test.format, test.definition)
+
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: error = %v, want %v", test.name, err, test.err)
+			continue
+		}
+		if schema.ID != test.id || schema.Version != test.version {
+			t.Errorf("%s: schema %d version %d, want %d version %d", test.name, schema.ID, schema.Version, test.id, test.version)
+		}
+	}
+
+	// A second process sees the schemas the first registered
+	reopened, err := NewFileRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := reopened.Latest(ctx, "points-value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.ID != 3 || latest.Version != 2 {
+		t.Errorf("latest = schema %d version %d, want 3 version 2", latest.ID, latest.Version)
+	}
+}
+
+func TestFileRegistryConcurrentProcesses(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "schemas.json")
+
+	// Every registry stands for a process, they only share the file
+	const processes, subjects = 8, 10
+	ids := make([][]int, processes)
+	errs := make([]error, processes)
+
+	var wg sync.WaitGroup
+	for i := 0; i < processes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			registry, err := NewFileRegistry(path)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			for j := 0; j < subjects; j++ {
+				schema, err := registry.Register(ctx, fmt.Sprintf("subject-%d-%d-value", i, j), FormatAvro, pointAvroSchema)
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				ids[i] = append(ids[i], schema.ID)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[int]bool{}
+	for i := range ids {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		for _, id := range ids[i] {
+			if seen[id] {
+				t.Errorf("schema ID %d assigned twice", id)
+			}
+			seen[id] = true
+		}
+	}
+
+	registry, err := NewFileRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < processes; i++ {
+		for j := 0; j < subjects; j++ {
+			if _, err := registry.Latest(ctx, fmt.Sprintf("subject-%d-%d-value", i, j)); err != nil {
+				t.Errorf("subject-%d-%d-value: %v", i, j, err)
+			}
+		}
+	}
+}
+
+func newFileRegistry(t *testing.T) *FileRegistry {
+	t.Helper()
+
+	registry, err := NewFileRegistry(filepath.Join(t.TempDir(), "schemas.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return registry
+}
+
+func newCodec(t *testing.T, format Format, definition string) Codec {
+	t.Helper()
+
+	var (
+		codec Codec
+		err   error
+	)
+	switch format {
+	case FormatJSONSchema:
+		codec, err = NewJSONSchemaCodec(definition)
+	case FormatAvro:
+		codec, err = NewAvroCodec(definition)
+	default:
+		t.Fatalf("no test codec for %s", format)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return codec
+}
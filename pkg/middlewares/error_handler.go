@@ -0,0 +1,79 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	exception "github.com/demo/rolldice/pkg/exceptions"
+	"github.com/labstack/echo/v4"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+var httpErrorCategories = map[int]exception.Category{
+	http.StatusBadRequest:            exception.CategoryValidation,
+	http.StatusUnauthorized:          exception.CategoryUnauthenticated,
+	http.StatusForbidden:             exception.CategoryForbidden,
+	http.StatusNotFound:              exception.CategoryNotFound,
+	http.StatusMethodNotAllowed:      exception.CategoryNotFound,
+	http.StatusRequestEntityTooLarge: exception.CategoryValidation,
+	http.StatusUnsupportedMediaType:  exception.CategoryValidation,
+	http.StatusTooManyRequests:       exception.CategoryRateLimited,
+	http.StatusServiceUnavailable:    exception.CategoryUnavailable,
+}
+
+// HTTPErrorHandler replies with RFC 7807 problem details, errors that are not domain errors become a bare 500
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	exc := toException(err)
+	status := exc.Status()
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		// Keep the status echo chose, e.g. 405 has no category of its own
+		status = httpErr.Code
+	}
+
+	problem := exc.Problem(c.Request().URL.Path)
+	problem.Status = status
+	problem.Title = http.StatusText(status)
+
+	if spanContext := oteltrace.SpanContextFromContext(c.Request().Context()); spanContext.HasTraceID() {
+		problem.TraceID = spanContext.TraceID().String()
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, exception.ProblemContentType)
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = c.JSON(status, problem)
+	}
+
+	if err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+// toException turns the errors echo raises itself, such as unknown routes, into domain errors
+func toException(err error) *exception.Error {
+	var httpErr *echo.HTTPError
+	if !errors.As(err, &httpErr) {
+		return exception.From(err)
+	}
+
+	category, ok := httpErrorCategories[httpErr.Code]
+	if !ok {
+		category = exception.CategoryInternal
+	}
+
+	message := http.StatusText(httpErr.Code)
+	if text, ok := httpErr.Message.(string); ok && httpErr.Code < http.StatusInternalServerError {
+		message = text
+	}
+
+	return exception.New(fmt.Sprintf("http.%d", httpErr.Code), category, message).Wrap(err)
+}
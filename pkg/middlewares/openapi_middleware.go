@@ -0,0 +1,149 @@
+package middlewares
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	exception "github.com/demo/rolldice/pkg/exceptions"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+var errResponseDrift = exception.New("openapi.response_mismatch", exception.CategoryInternal, "response does not match the OpenAPI document")
+
+// OpenAPIValidator rejects requests that do not match doc with 400, routes missing from doc such as probes and the gRPC gateway pass through.
+// With validateResponses, JSON responses are buffered and replaced by a 500 when they drift from doc
+func OpenAPIValidator(doc *openapi3.T, validateResponses bool) (echo.MiddlewareFunc, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			request := c.Request()
+
+			route, pathParams, err := router.FindRoute(request)
+			if err != nil {
+				return next(c)
+			}
+
+			ctx := request.Context()
+			span := oteltrace.SpanFromContext(ctx)
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    request,
+				PathParams: pathParams,
+				Route:      route,
+				Options: &openapi3filter.Options{
+					AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				},
+			}
+
+			if err := openapi3filter.ValidateRequest(ctx, input); err != nil {
+				span.SetAttributes(attribute.String("app.openapi.request_error", err.Error()))
+				return exception.ErrInvalidRequest.WithMessage(validationMessage(err)).Wrap(err)
+			}
+
+			if !validateResponses || !jsonResponses(route) {
+				return next(c)
+			}
+
+			response := c.Response()
+			writer := response.Writer
+			buffer := &responseBuffer{ResponseWriter: writer, status: http.StatusOK}
+			response.Writer = buffer
+
+			err = next(c)
+
+			response.Writer = writer
+
+			if err != nil {
+				// Nothing was written, the error handler replies once the error bubbles up
+				return err
+			}
+
+			if err := openapi3filter.ValidateResponse(ctx, &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: input,
+				Status:                 buffer.status,
+				Header:                 writer.Header(),
+				Body:                   io.NopCloser(bytes.NewReader(buffer.body.Bytes())),
+				Options: &openapi3filter.Options{
+					IncludeResponseStatus: true,
+				},
+			}); err != nil {
+				// The buffered response was never sent, let the error handler reply instead
+				writer.Header().Del(echo.HeaderContentLength)
+				response.Committed = false
+				response.Size = 0
+				return errResponseDrift.Wrap(err)
+			}
+
+			writer.WriteHeader(buffer.status)
+			_, err = writer.Write(buffer.body.Bytes())
+			return err
+		}
+	}, nil
+}
+
+// jsonResponses tells whether every success response of the route is JSON, streams are never buffered
+func jsonResponses(route *routers.Route) bool {
+	for status, response := range route.Operation.Responses.Map() {
+		if !strings.HasPrefix(status, "2") || response.Value == nil {
+			continue
+		}
+		if response.Value.Content.Get(echo.MIMEApplicationJSON) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func validationMessage(err error) string {
+	var requestErr *openapi3filter.RequestError
+	if errors.As(err, &requestErr) {
+		if requestErr.Parameter != nil {
+			return "invalid parameter " + requestErr.Parameter.Name + ": " + rootCause(requestErr.Err)
+		}
+		if requestErr.RequestBody != nil {
+			return "invalid request body: " + rootCause(requestErr.Err)
+		}
+	}
+	return err.Error()
+}
+
+func rootCause(err error) string {
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+			return strings.Join(pointer, ".") + " " + schemaErr.Reason
+		}
+		return schemaErr.Reason
+	}
+	if err == nil {
+		return "invalid value"
+	}
+	return err.Error()
+}
+
+type responseBuffer struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *responseBuffer) Write(data []byte) (int, error) {
+	return b.body.Write(data)
+}
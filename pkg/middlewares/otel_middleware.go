@@ -3,6 +3,7 @@ package middlewares
 import (
 	"fmt"
 
+	"github.com/demo/rolldice/pkg/health"
 	"github.com/labstack/echo/v4"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -12,9 +13,19 @@ import (
 	oteltrace "go.opentelemetry.io/otel/trace"
 )
 
+// untracedPaths are polled by the orchestrator every few seconds, their spans would only be noise
+var untracedPaths = map[string]bool{
+	health.LivenessPath:  true,
+	health.ReadinessPath: true,
+}
+
 func OtelMiddleware(appName string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			if untracedPaths[c.Path()] {
+				return next(c)
+			}
+
 			tracerProvider := otel.GetTracerProvider()
 			tracer := tracerProvider.Tracer("main")
 
@@ -50,9 +61,16 @@ func OtelMiddleware(appName string) echo.MiddlewareFunc {
 
 			err := next(c)
 			if err != nil {
+				exc := toException(err)
+				errorAttributes := []attribute.KeyValue{
+					attribute.String("app.error.code", exc.Code),
+					attribute.String("app.error.category", string(exc.Category)),
+					attribute.Bool("app.error.retryable", exc.Retryable),
+				}
+
 				span.SetAttributes(attribute.String("echo.error", err.Error()))
-				status := c.Response().Status
-				span.SetStatus(httpconv.ServerStatus(status))
+				span.SetAttributes(errorAttributes...)
+				span.RecordError(err, oteltrace.WithAttributes(errorAttributes...))
 				c.Error(err)
 			}
 
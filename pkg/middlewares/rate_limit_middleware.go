@@ -0,0 +1,261 @@
+package middlewares
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	exception "github.com/demo/rolldice/pkg/exceptions"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+
+	bucketSweepInterval = time.Minute
+)
+
+// RateLimit lets Limit requests through per Window, bursts up to Burst requests, Limit when zero
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+	Burst  int
+}
+
+func (l RateLimit) enabled() bool {
+	return l.Limit > 0 && l.Window > 0
+}
+
+func (l RateLimit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Limit)
+}
+
+// rate is the number of tokens refilled per second
+func (l RateLimit) rate() float64 {
+	return float64(l.Limit) / l.Window.Seconds()
+}
+
+// KeyExtractor identifies the client of a request, class labels the kind of key in metrics, e.g. "ip".
+// ok is false when the extractor does not apply, the next one is tried
+type KeyExtractor func(c echo.Context) (key, class string, ok bool)
+
+// KeyByIP keys requests by the IP the echo.IPExtractor of the server finds, see IPExtractor
+func KeyByIP(c echo.Context) (string, string, bool) {
+	return c.RealIP(), "ip", true
+}
+
+// IPExtractor takes the client IP from X-Forwarded-For only for connections from trustedProxies, and the address
+// of the connection otherwise, so a client cannot pick the IP it is limited by
+func IPExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range trustedProxies {
+		options = append(options, echo.TrustIPRange(proxy))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// KeyByHeader keys requests by the value of header, e.g. an API key
+func KeyByHeader(header, class string) KeyExtractor {
+	return func(c echo.Context) (string, string, bool) {
+		value := c.Request().Header.Get(header)
+		return value, class, value != ""
+	}
+}
+
+type RateLimitConfig struct {
+	Skipper middleware.Skipper
+	// Default applies to routes missing from Routes, requests are not limited when it is zero
+	Default RateLimit
+	// Routes are keyed by method and echo route, e.g. "POST /sessions/:id/rolls", each has its own buckets
+	Routes map[string]RateLimit
+	// Route names the route of a request in Routes and metrics, c.Path() when nil
+	Route func(c echo.Context) string
+	// Extractors are tried in order, KeyByIP is the fallback
+	Extractors []KeyExtractor
+}
+
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	capacity float64
+	rate     float64
+}
+
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.updated).Seconds()*b.rate)
+	b.updated = now
+}
+
+type rateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	allowed   metric.Int64Counter
+	rejected  metric.Int64Counter
+}
+
+// RateLimiter fails with exception.ErrRateLimited, a 429, and sets Retry-After once a client used up its token bucket, every response carries the
+// RateLimit-* headers of the bucket. Allowed and rejected requests are counted by key class
+func RateLimiter(config RateLimitConfig, meter metric.Meter) (echo.MiddlewareFunc, error) {
+	if config.Skipper == nil {
+		config.Skipper = middleware.DefaultSkipper
+	}
+
+	if config.Route == nil {
+		config.Route = func(c echo.Context) string { return c.Path() }
+	}
+
+	allowed, err := meter.Int64Counter(
+		"app.ratelimit.allowed",
+		metric.WithDescription("Requests let through by the rate limiter"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rejected, err := meter.Int64Counter(
+		"app.ratelimit.rejected",
+		metric.WithDescription("Requests rejected by the rate limiter"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter := &rateLimiter{
+		buckets:   map[string]*bucket{},
+		lastSweep: time.Now(),
+		allowed:   allowed,
+		rejected:  rejected,
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			path := config.Route(c)
+			route := c.Request().Method + " " + path
+
+			limit, ok := config.Routes[route]
+			scope := route
+			if !ok {
+				limit = config.Default
+				scope = ""
+			}
+
+			if !limit.enabled() {
+				return next(c)
+			}
+
+			key, class := clientKey(c, config.Extractors)
+
+			remaining, reset, retryAfter := limiter.take(scope+"|"+class+"|"+key, limit, time.Now())
+
+			header := c.Response().Header()
+			header.Set(RateLimitLimitHeader, strconv.Itoa(int(limit.capacity())))
+			header.Set(RateLimitRemainingHeader, strconv.Itoa(remaining))
+			header.Set(RateLimitResetHeader, strconv.Itoa(seconds(reset)))
+			header.Set(RateLimitPolicyHeader, fmt.Sprintf("%d;w=%d;burst=%d", limit.Limit, seconds(limit.Window), int(limit.capacity())))
+
+			ctx := c.Request().Context()
+			attributes := metric.WithAttributes(
+				attribute.String("app.ratelimit.key_class", class),
+				semconv.HTTPRoute(path),
+			)
+
+			if retryAfter > 0 {
+				limiter.rejected.Add(ctx, 1, attributes)
+
+				oteltrace.SpanFromContext(ctx).SetAttributes(
+					attribute.Bool("app.ratelimit.rejected", true),
+					attribute.String("app.ratelimit.key_class", class),
+				)
+
+				header.Set(echo.HeaderRetryAfter, strconv.Itoa(seconds(retryAfter)))
+				return exception.ErrRateLimited
+			}
+
+			limiter.allowed.Add(ctx, 1, attributes)
+
+			return next(c)
+		}
+	}, nil
+}
+
+func clientKey(c echo.Context, extractors []KeyExtractor) (string, string) {
+	for _, extractor := range extractors {
+		if key, class, ok := extractor(c); ok {
+			return key, class
+		}
+	}
+
+	key, class, _ := KeyByIP(c)
+	return key, class
+}
+
+// take spends a token of the bucket behind key, retryAfter is zero when the request is allowed
+func (l *rateLimiter) take(key string, limit RateLimit, now time.Time) (remaining int, reset, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit.capacity(), updated: now, capacity: limit.capacity(), rate: limit.rate()}
+		l.buckets[key] = b
+	}
+
+	b.refill(now)
+
+	if b.tokens < 1 {
+		retryAfter = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	} else {
+		b.tokens--
+	}
+
+	reset = time.Duration((b.capacity - b.tokens) / b.rate * float64(time.Second))
+
+	return int(b.tokens), reset, retryAfter
+}
+
+// sweep forgets buckets that filled up again, a full bucket is the same as no bucket
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketSweepInterval {
+		return
+	}
+
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= b.capacity {
+			delete(l.buckets, key)
+		}
+	}
+
+	l.lastSweep = now
+}
+
+// seconds rounds up so clients never retry too early
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,194 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	exception "github.com/demo/rolldice/pkg/exceptions"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+func TestTokenBucketRefill(t *testing.T) {
+	limit := RateLimit{Limit: 2, Window: time.Second}
+	start := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		at         time.Duration
+		remaining  int
+		retryAfter time.Duration
+	}{
+		{0, 1, 0},
+		{0, 0, 0},
+		{0, 0, 500 * time.Millisecond},
+		{250 * time.Millisecond, 0, 250 * time.Millisecond},
+		{500 * time.Millisecond, 0, 0},
+		// Tokens never pile up beyond the capacity
+		{10 * time.Second, 1, 0},
+		{10 * time.Second, 0, 0},
+	}
+
+	limiter := &rateLimiter{buckets: map[string]*bucket{}, lastSweep: start}
+
+	for i, test := range tests {
+		remaining, _, retryAfter := limiter.take("client", limit, start.Add(test.at))
+
+		if remaining != test.remaining || retryAfter != test.retryAfter {
+			t.Errorf("request %d at %v = (%d, %v), want (%d, %v)", i, test.at, remaining, retryAfter, test.remaining, test.retryAfter)
+		}
+	}
+}
+
+func TestTokenBucketBurst(t *testing.T) {
+	limit := RateLimit{Limit: 1, Window: time.Minute, Burst: 3}
+	now := time.Unix(1700000000, 0)
+	limiter := &rateLimiter{buckets: map[string]*bucket{}, lastSweep: now}
+
+	for i := 0; i < 3; i++ {
+		if _, _, retryAfter := limiter.take("client", limit, now); retryAfter != 0 {
+			t.Fatalf("request %d of the burst was rejected", i)
+		}
+	}
+
+	if _, reset, retryAfter := limiter.take("client", limit, now); retryAfter != time.Minute || reset != 3*time.Minute {
+		t.Errorf("request after the burst = retry after %v, reset %v, want %v and %v", retryAfter, reset, time.Minute, 3*time.Minute)
+	}
+}
+
+func TestTokenBucketSweep(t *testing.T) {
+	limit := RateLimit{Limit: 10, Window: time.Minute}
+	start := time.Unix(1700000000, 0)
+	limiter := &rateLimiter{buckets: map[string]*bucket{}, lastSweep: start}
+
+	limiter.take("idle", limit, start)
+	for i := 0; i < 10; i++ {
+		limiter.take("busy", limit, start.Add(50*time.Second))
+	}
+
+	// Before the sweep interval nothing is forgotten
+	limiter.take("other", limit, start.Add(bucketSweepInterval/2))
+	if len(limiter.buckets) != 3 {
+		t.Fatalf("%d buckets before the sweep, want 3", len(limiter.buckets))
+	}
+
+	limiter.take("other", limit, start.Add(bucketSweepInterval))
+
+	if _, ok := limiter.buckets["idle"]; ok {
+		t.Error("the refilled bucket of an idle client was kept")
+	}
+	if _, ok := limiter.buckets["busy"]; !ok {
+		t.Error("the bucket of a client still limited was forgotten")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	rateLimiter, err := RateLimiter(RateLimitConfig{
+		Default: RateLimit{Limit: 1, Window: time.Minute},
+		Routes: map[string]RateLimit{
+			"POST /rolls": {Limit: 2, Window: time.Minute},
+		},
+		Extractors: []KeyExtractor{KeyByHeader("X-API-Key", "api_key")},
+	}, noop.NewMeterProvider().Meter(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	handler := rateLimiter(func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		apiKey string
+		ip     string
+		err    error
+		policy string
+	}{
+		{"first request of an IP", http.MethodGet, "/roll", "", "10.0.0.1", nil, "1;w=60;burst=1"},
+		{"same IP", http.MethodGet, "/roll", "", "10.0.0.1", exception.ErrRateLimited, "1;w=60;burst=1"},
+		{"other IP", http.MethodGet, "/roll", "", "10.0.0.2", nil, "1;w=60;burst=1"},
+		{"API key behind a limited IP", http.MethodGet, "/roll", "key", "10.0.0.1", nil, "1;w=60;burst=1"},
+		{"route limit has its own bucket", http.MethodPost, "/rolls", "", "10.0.0.1", nil, "2;w=60;burst=2"},
+		{"route limit", http.MethodPost, "/rolls", "", "10.0.0.1", nil, "2;w=60;burst=2"},
+		{"route limit used up", http.MethodPost, "/rolls", "", "10.0.0.1", exception.ErrRateLimited, "2;w=60;burst=2"},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(test.method, test.path, nil)
+		request.RemoteAddr = test.ip + ":1234"
+		if test.apiKey != "" {
+			request.Header.Set("X-API-Key", test.apiKey)
+		}
+		recorder := httptest.NewRecorder()
+		c := e.NewContext(request, recorder)
+		c.SetPath(test.path)
+
+		err := handler(c)
+
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: error = %v, want %v", test.name, err, test.err)
+		}
+		if policy := recorder.Header().Get(RateLimitPolicyHeader); policy != test.policy {
+			t.Errorf("%s: %s = %q, want %q", test.name, RateLimitPolicyHeader, policy, test.policy)
+		}
+		if test.err != nil && recorder.Header().Get(echo.HeaderRetryAfter) == "" {
+			t.Errorf("%s: rejected without %s", test.name, echo.HeaderRetryAfter)
+		}
+	}
+}
+
+func TestRateLimiterIgnoresSpoofedForwardedFor(t *testing.T) {
+	_, trusted, err := net.ParseCIDR("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		proxies []*net.IPNet
+		ip      string
+		// Both requests claim a different X-Forwarded-For
+		rejected bool
+	}{
+		{"no trusted proxy", nil, "203.0.113.7", true},
+		{"untrusted proxy", []*net.IPNet{trusted}, "203.0.113.7", true},
+		{"trusted proxy", []*net.IPNet{trusted}, "10.0.0.1", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rateLimiter, err := RateLimiter(RateLimitConfig{
+				Default: RateLimit{Limit: 1, Window: time.Minute},
+			}, noop.NewMeterProvider().Meter(""))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			e := echo.New()
+			e.IPExtractor = IPExtractor(test.proxies)
+			handler := rateLimiter(func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+
+			var last error
+			for i := 0; i < 2; i++ {
+				request := httptest.NewRequest(http.MethodGet, "/roll", nil)
+				request.RemoteAddr = test.ip + ":1234"
+				request.Header.Set(echo.HeaderXForwardedFor, fmt.Sprintf("198.51.100.%d", i+1))
+				request.Header.Set(echo.HeaderXRealIP, fmt.Sprintf("198.51.100.%d", i+1))
+				c := e.NewContext(request, httptest.NewRecorder())
+				c.SetPath("/roll")
+
+				last = handler(c)
+			}
+
+			if rejected := errors.Is(last, exception.ErrRateLimited); rejected != test.rejected {
+				t.Errorf("second request rejected = %v, want %v", rejected, test.rejected)
+			}
+		})
+	}
+}
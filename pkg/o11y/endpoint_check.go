@@ -0,0 +1,28 @@
+package o11y
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+const defaultOtlpHttpPort = "4318"
+
+// EndpointCheck dials the OTLP endpoint, telemetry is dropped while it is unreachable
+func EndpointCheck(otlpEndpoint string) func(ctx context.Context) error {
+	address := otlpEndpoint
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, defaultOtlpHttpPort)
+	}
+
+	return func(ctx context.Context) error {
+		var dialer net.Dialer
+
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return fmt.Errorf("failed to reach OTLP endpoint: %w", err)
+		}
+
+		return conn.Close()
+	}
+}
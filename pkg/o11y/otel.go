@@ -2,6 +2,7 @@ package o11y
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -89,12 +90,14 @@ func createLoggerProvider(resource *sdkresource.Resource, exporter *otlploghttp.
 }
 
 type InitResult struct {
-	Shutdown       func()
+	Shutdown       func(ctx context.Context) error
 	LoggerProvider *sdklog.LoggerProvider
+	MeterProvider  *sdkmetric.MeterProvider
 }
 
 func InitOTel(config *config.InitOTelConfig) InitResult {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
 	resource, err := createResource(ctx, config.AppName)
 	exceptions.Print(err, "Error creating OTLP resource")
@@ -112,6 +115,7 @@ func InitOTel(config *config.InitOTelConfig) InitResult {
 	exceptions.Print(err, "Error creating Metric exporter")
 	metricProvider, err := createMeterProvider(resource, metricExporter)
 	exceptions.Print(err, "Error creating Metric provider")
+	otel.SetMeterProvider(metricProvider)
 
 	// Create log exporter and logger provider
 	logExporter, err := createLogExporter(ctx, config.OtlpEndpoint, config.HttpExporterAuthToken)
@@ -119,12 +123,15 @@ func InitOTel(config *config.InitOTelConfig) InitResult {
 	loggerProvider := createLoggerProvider(resource, logExporter)
 
 	return InitResult{
-		Shutdown: func() {
-			exceptions.Print(traceProvider.Shutdown(ctx), "Error shutting down Trace provider")
-			exceptions.Print(metricProvider.Shutdown(ctx), "Error shutting down Metric provider")
-			exceptions.Print(loggerProvider.Shutdown(ctx), "Error shutting down Log provider")
-			cancel()
+		// Shutdown flushes buffered spans, metrics and logs, ctx bounds how long the exporters may take
+		Shutdown: func(ctx context.Context) error {
+			return errors.Join(
+				traceProvider.Shutdown(ctx),
+				metricProvider.Shutdown(ctx),
+				loggerProvider.Shutdown(ctx),
+			)
 		},
 		LoggerProvider: loggerProvider,
+		MeterProvider:  metricProvider,
 	}
 }
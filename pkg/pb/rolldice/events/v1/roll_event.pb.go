@@ -0,0 +1,509 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// 	protoc        (unknown)
+// source: rolldice/events/v1/roll_event.proto
+
+package eventsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// RollEvent is published to poc.rolldice for every roll, it imports nothing so its schema stands alone in the registry
+type RollEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RollId     string    `protobuf:"bytes,1,opt,name=roll_id,json=rollId,proto3" json:"roll_id,omitempty"`
+	Expression string    `protobuf:"bytes,2,opt,name=expression,proto3" json:"expression,omitempty"`
+	Terms      []*Term   `protobuf:"bytes,3,rep,name=terms,proto3" json:"terms,omitempty"`
+	Result     int32     `protobuf:"varint,4,opt,name=result,proto3" json:"result,omitempty"`
+	RollerId   string    `protobuf:"bytes,5,opt,name=roller_id,json=rollerId,proto3" json:"roller_id,omitempty"`
+	SessionId  string    `protobuf:"bytes,6,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Fairness   *Fairness `protobuf:"bytes,7,opt,name=fairness,proto3" json:"fairness,omitempty"`
+	// RFC 3339
+	Timestamp string `protobuf:"bytes,8,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+}
+
+func (x *RollEvent) Reset() {
+	*x = RollEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rolldice_events_v1_roll_event_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RollEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RollEvent) ProtoMessage() {}
+
+func (x *RollEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_rolldice_events_v1_roll_event_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RollEvent.ProtoReflect.Descriptor instead.
+func (*RollEvent) Descriptor() ([]byte, []int) {
+	return file_rolldice_events_v1_roll_event_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RollEvent) GetRollId() string {
+	if x != nil {
+		return x.RollId
+	}
+	return ""
+}
+
+func (x *RollEvent) GetExpression() string {
+	if x != nil {
+		return x.Expression
+	}
+	return ""
+}
+
+func (x *RollEvent) GetTerms() []*Term {
+	if x != nil {
+		return x.Terms
+	}
+	return nil
+}
+
+func (x *RollEvent) GetResult() int32 {
+	if x != nil {
+		return x.Result
+	}
+	return 0
+}
+
+func (x *RollEvent) GetRollerId() string {
+	if x != nil {
+		return x.RollerId
+	}
+	return ""
+}
+
+func (x *RollEvent) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *RollEvent) GetFairness() *Fairness {
+	if x != nil {
+		return x.Fairness
+	}
+	return nil
+}
+
+func (x *RollEvent) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+type Die struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Value    int32   `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+	Kept     bool    `protobuf:"varint,2,opt,name=kept,proto3" json:"kept,omitempty"`
+	Exploded bool    `protobuf:"varint,3,opt,name=exploded,proto3" json:"exploded,omitempty"`
+	Rerolled []int32 `protobuf:"varint,4,rep,packed,name=rerolled,proto3" json:"rerolled,omitempty"`
+}
+
+func (x *Die) Reset() {
+	*x = Die{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rolldice_events_v1_roll_event_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Die) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Die) ProtoMessage() {}
+
+func (x *Die) ProtoReflect() protoreflect.Message {
+	mi := &file_rolldice_events_v1_roll_event_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Die.ProtoReflect.Descriptor instead.
+func (*Die) Descriptor() ([]byte, []int) {
+	return file_rolldice_events_v1_roll_event_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Die) GetValue() int32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *Die) GetKept() bool {
+	if x != nil {
+		return x.Kept
+	}
+	return false
+}
+
+func (x *Die) GetExploded() bool {
+	if x != nil {
+		return x.Exploded
+	}
+	return false
+}
+
+func (x *Die) GetRerolled() []int32 {
+	if x != nil {
+		return x.Rerolled
+	}
+	return nil
+}
+
+type Term struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Notation string `protobuf:"bytes,1,opt,name=notation,proto3" json:"notation,omitempty"`
+	Sign     int32  `protobuf:"varint,2,opt,name=sign,proto3" json:"sign,omitempty"`
+	Sides    int32  `protobuf:"varint,3,opt,name=sides,proto3" json:"sides,omitempty"`
+	Dice     []*Die `protobuf:"bytes,4,rep,name=dice,proto3" json:"dice,omitempty"`
+	Subtotal int32  `protobuf:"varint,5,opt,name=subtotal,proto3" json:"subtotal,omitempty"`
+}
+
+func (x *Term) Reset() {
+	*x = Term{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rolldice_events_v1_roll_event_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Term) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Term) ProtoMessage() {}
+
+func (x *Term) ProtoReflect() protoreflect.Message {
+	mi := &file_rolldice_events_v1_roll_event_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Term.ProtoReflect.Descriptor instead.
+func (*Term) Descriptor() ([]byte, []int) {
+	return file_rolldice_events_v1_roll_event_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Term) GetNotation() string {
+	if x != nil {
+		return x.Notation
+	}
+	return ""
+}
+
+func (x *Term) GetSign() int32 {
+	if x != nil {
+		return x.Sign
+	}
+	return 0
+}
+
+func (x *Term) GetSides() int32 {
+	if x != nil {
+		return x.Sides
+	}
+	return 0
+}
+
+func (x *Term) GetDice() []*Die {
+	if x != nil {
+		return x.Dice
+	}
+	return nil
+}
+
+func (x *Term) GetSubtotal() int32 {
+	if x != nil {
+		return x.Subtotal
+	}
+	return 0
+}
+
+type Fairness struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ServerSeedId   string `protobuf:"bytes,1,opt,name=server_seed_id,json=serverSeedId,proto3" json:"server_seed_id,omitempty"`
+	ServerSeedHash string `protobuf:"bytes,2,opt,name=server_seed_hash,json=serverSeedHash,proto3" json:"server_seed_hash,omitempty"`
+	ClientSeed     string `protobuf:"bytes,3,opt,name=client_seed,json=clientSeed,proto3" json:"client_seed,omitempty"`
+	Nonce          int64  `protobuf:"varint,4,opt,name=nonce,proto3" json:"nonce,omitempty"`
+}
+
+func (x *Fairness) Reset() {
+	*x = Fairness{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rolldice_events_v1_roll_event_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Fairness) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Fairness) ProtoMessage() {}
+
+func (x *Fairness) ProtoReflect() protoreflect.Message {
+	mi := &file_rolldice_events_v1_roll_event_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Fairness.ProtoReflect.Descriptor instead.
+func (*Fairness) Descriptor() ([]byte, []int) {
+	return file_rolldice_events_v1_roll_event_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Fairness) GetServerSeedId() string {
+	if x != nil {
+		return x.ServerSeedId
+	}
+	return ""
+}
+
+func (x *Fairness) GetServerSeedHash() string {
+	if x != nil {
+		return x.ServerSeedHash
+	}
+	return ""
+}
+
+func (x *Fairness) GetClientSeed() string {
+	if x != nil {
+		return x.ClientSeed
+	}
+	return ""
+}
+
+func (x *Fairness) GetNonce() int64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+var File_rolldice_events_v1_roll_event_proto protoreflect.FileDescriptor
+
+var file_rolldice_events_v1_roll_event_proto_rawDesc = []byte{
+	0x0a, 0x23, 0x72, 0x6f, 0x6c, 0x6c, 0x64, 0x69, 0x63, 0x65, 0x2f, 0x65, 0x76, 0x65, 0x6e, 0x74,
+	0x73, 0x2f, 0x76, 0x31, 0x2f, 0x72, 0x6f, 0x6c, 0x6c, 0x5f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x12, 0x72, 0x6f, 0x6c, 0x6c, 0x64, 0x69, 0x63, 0x65, 0x2e,
+	0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x22, 0xa0, 0x02, 0x0a, 0x09, 0x52, 0x6f,
+	0x6c, 0x6c, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x72, 0x6f, 0x6c, 0x6c, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x6f, 0x6c, 0x6c, 0x49, 0x64,
+	0x12, 0x1e, 0x0a, 0x0a, 0x65, 0x78, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x65, 0x78, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x12, 0x2e, 0x0a, 0x05, 0x74, 0x65, 0x72, 0x6d, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x18, 0x2e, 0x72, 0x6f, 0x6c, 0x6c, 0x64, 0x69, 0x63, 0x65, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74,
+	0x73, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x65, 0x72, 0x6d, 0x52, 0x05, 0x74, 0x65, 0x72, 0x6d, 0x73,
+	0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x72, 0x6f, 0x6c, 0x6c,
+	0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x6f, 0x6c,
+	0x6c, 0x65, 0x72, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x5f, 0x69, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x49, 0x64, 0x12, 0x38, 0x0a, 0x08, 0x66, 0x61, 0x69, 0x72, 0x6e, 0x65, 0x73, 0x73,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x72, 0x6f, 0x6c, 0x6c, 0x64, 0x69, 0x63,
+	0x65, 0x2e, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x61, 0x69, 0x72,
+	0x6e, 0x65, 0x73, 0x73, 0x52, 0x08, 0x66, 0x61, 0x69, 0x72, 0x6e, 0x65, 0x73, 0x73, 0x12, 0x1c,
+	0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x22, 0x67, 0x0a, 0x03,
+	0x44, 0x69, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6b, 0x65, 0x70,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x6b, 0x65, 0x70, 0x74, 0x12, 0x1a, 0x0a,
+	0x08, 0x65, 0x78, 0x70, 0x6c, 0x6f, 0x64, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x08, 0x65, 0x78, 0x70, 0x6c, 0x6f, 0x64, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x72,
+	0x6f, 0x6c, 0x6c, 0x65, 0x64, 0x18, 0x04, 0x20, 0x03, 0x28, 0x05, 0x52, 0x08, 0x72, 0x65, 0x72,
+	0x6f, 0x6c, 0x6c, 0x65, 0x64, 0x22, 0x95, 0x01, 0x0a, 0x04, 0x54, 0x65, 0x72, 0x6d, 0x12, 0x1a,
+	0x0a, 0x08, 0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69,
+	0x67, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x73, 0x69, 0x67, 0x6e, 0x12, 0x14,
+	0x0a, 0x05, 0x73, 0x69, 0x64, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x73,
+	0x69, 0x64, 0x65, 0x73, 0x12, 0x2b, 0x0a, 0x04, 0x64, 0x69, 0x63, 0x65, 0x18, 0x04, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x17, 0x2e, 0x72, 0x6f, 0x6c, 0x6c, 0x64, 0x69, 0x63, 0x65, 0x2e, 0x65, 0x76,
+	0x65, 0x6e, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x69, 0x65, 0x52, 0x04, 0x64, 0x69, 0x63,
+	0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x75, 0x62, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x08, 0x73, 0x75, 0x62, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x22, 0x91, 0x01,
+	0x0a, 0x08, 0x46, 0x61, 0x69, 0x72, 0x6e, 0x65, 0x73, 0x73, 0x12, 0x24, 0x0a, 0x0e, 0x73, 0x65,
+	0x72, 0x76, 0x65, 0x72, 0x5f, 0x73, 0x65, 0x65, 0x64, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0c, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x53, 0x65, 0x65, 0x64, 0x49, 0x64,
+	0x12, 0x28, 0x0a, 0x10, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x73, 0x65, 0x65, 0x64, 0x5f,
+	0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x73, 0x65, 0x72, 0x76,
+	0x65, 0x72, 0x53, 0x65, 0x65, 0x64, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1f, 0x0a, 0x0b, 0x63, 0x6c,
+	0x69, 0x65, 0x6e, 0x74, 0x5f, 0x73, 0x65, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0a, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x53, 0x65, 0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x6e,
+	0x6f, 0x6e, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63,
+	0x65, 0x42, 0x3d, 0x5a, 0x3b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x64, 0x65, 0x6d, 0x6f, 0x2f, 0x72, 0x6f, 0x6c, 0x6c, 0x64, 0x69, 0x63, 0x65, 0x2f, 0x70, 0x6b,
+	0x67, 0x2f, 0x70, 0x62, 0x2f, 0x72, 0x6f, 0x6c, 0x6c, 0x64, 0x69, 0x63, 0x65, 0x2f, 0x65, 0x76,
+	0x65, 0x6e, 0x74, 0x73, 0x2f, 0x76, 0x31, 0x3b, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x76, 0x31,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_rolldice_events_v1_roll_event_proto_rawDescOnce sync.Once
+	file_rolldice_events_v1_roll_event_proto_rawDescData = file_rolldice_events_v1_roll_event_proto_rawDesc
+)
+
+func file_rolldice_events_v1_roll_event_proto_rawDescGZIP() []byte {
+	file_rolldice_events_v1_roll_event_proto_rawDescOnce.Do(func() {
+		file_rolldice_events_v1_roll_event_proto_rawDescData = protoimpl.X.CompressGZIP(file_rolldice_events_v1_roll_event_proto_rawDescData)
+	})
+	return file_rolldice_events_v1_roll_event_proto_rawDescData
+}
+
+var file_rolldice_events_v1_roll_event_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_rolldice_events_v1_roll_event_proto_goTypes = []interface{}{
+	(*RollEvent)(nil), // 0: rolldice.events.v1.RollEvent
+	(*Die)(nil),       // 1: rolldice.events.v1.Die
+	(*Term)(nil),      // 2: rolldice.events.v1.Term
+	(*Fairness)(nil),  // 3: rolldice.events.v1.Fairness
+}
+var file_rolldice_events_v1_roll_event_proto_depIdxs = []int32{
+	2, // 0: rolldice.events.v1.RollEvent.terms:type_name -> rolldice.events.v1.Term
+	3, // 1: rolldice.events.v1.RollEvent.fairness:type_name -> rolldice.events.v1.Fairness
+	1, // 2: rolldice.events.v1.Term.dice:type_name -> rolldice.events.v1.Die
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_rolldice_events_v1_roll_event_proto_init() }
+func file_rolldice_events_v1_roll_event_proto_init() {
+	if File_rolldice_events_v1_roll_event_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_rolldice_events_v1_roll_event_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RollEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rolldice_events_v1_roll_event_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Die); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rolldice_events_v1_roll_event_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Term); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rolldice_events_v1_roll_event_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Fairness); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_rolldice_events_v1_roll_event_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_rolldice_events_v1_roll_event_proto_goTypes,
+		DependencyIndexes: file_rolldice_events_v1_roll_event_proto_depIdxs,
+		MessageInfos:      file_rolldice_events_v1_roll_event_proto_msgTypes,
+	}.Build()
+	File_rolldice_events_v1_roll_event_proto = out.File
+	file_rolldice_events_v1_roll_event_proto_rawDesc = nil
+	file_rolldice_events_v1_roll_event_proto_goTypes = nil
+	file_rolldice_events_v1_roll_event_proto_depIdxs = nil
+}
@@ -0,0 +1,184 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: rolldice/v1/rolldice.proto
+
+package rolldicev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	RollDiceService_Roll_FullMethodName       = "/rolldice.v1.RollDiceService/Roll"
+	RollDiceService_WatchRolls_FullMethodName = "/rolldice.v1.RollDiceService/WatchRolls"
+)
+
+// RollDiceServiceClient is the client API for RollDiceService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// RollDiceService rolls dice expressions such as "3d6+2" or "4d6kh3"
+type RollDiceServiceClient interface {
+	// Roll rolls, stores and publishes a single expression
+	Roll(ctx context.Context, in *RollRequest, opts ...grpc.CallOption) (*RollResponse, error)
+	// WatchRolls streams every roll as it happens, after replaying the ones made since last_event_id
+	WatchRolls(ctx context.Context, in *WatchRollsRequest, opts ...grpc.CallOption) (RollDiceService_WatchRollsClient, error)
+}
+
+type rollDiceServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRollDiceServiceClient(cc grpc.ClientConnInterface) RollDiceServiceClient {
+	return &rollDiceServiceClient{cc}
+}
+
+func (c *rollDiceServiceClient) Roll(ctx context.Context, in *RollRequest, opts ...grpc.CallOption) (*RollResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RollResponse)
+	err := c.cc.Invoke(ctx, RollDiceService_Roll_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rollDiceServiceClient) WatchRolls(ctx context.Context, in *WatchRollsRequest, opts ...grpc.CallOption) (RollDiceService_WatchRollsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RollDiceService_ServiceDesc.Streams[0], RollDiceService_WatchRolls_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &rollDiceServiceWatchRollsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type RollDiceService_WatchRollsClient interface {
+	Recv() (*WatchRollsResponse, error)
+	grpc.ClientStream
+}
+
+type rollDiceServiceWatchRollsClient struct {
+	grpc.ClientStream
+}
+
+func (x *rollDiceServiceWatchRollsClient) Recv() (*WatchRollsResponse, error) {
+	m := new(WatchRollsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// RollDiceServiceServer is the server API for RollDiceService service.
+// All implementations must embed UnimplementedRollDiceServiceServer
+// for forward compatibility
+//
+// RollDiceService rolls dice expressions such as "3d6+2" or "4d6kh3"
+type RollDiceServiceServer interface {
+	// Roll rolls, stores and publishes a single expression
+	Roll(context.Context, *RollRequest) (*RollResponse, error)
+	// WatchRolls streams every roll as it happens, after replaying the ones made since last_event_id
+	WatchRolls(*WatchRollsRequest, RollDiceService_WatchRollsServer) error
+	mustEmbedUnimplementedRollDiceServiceServer()
+}
+
+// UnimplementedRollDiceServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedRollDiceServiceServer struct {
+}
+
+func (UnimplementedRollDiceServiceServer) Roll(context.Context, *RollRequest) (*RollResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Roll not implemented")
+}
+func (UnimplementedRollDiceServiceServer) WatchRolls(*WatchRollsRequest, RollDiceService_WatchRollsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchRolls not implemented")
+}
+func (UnimplementedRollDiceServiceServer) mustEmbedUnimplementedRollDiceServiceServer() {}
+
+// UnsafeRollDiceServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RollDiceServiceServer will
+// result in compilation errors.
+type UnsafeRollDiceServiceServer interface {
+	mustEmbedUnimplementedRollDiceServiceServer()
+}
+
+func RegisterRollDiceServiceServer(s grpc.ServiceRegistrar, srv RollDiceServiceServer) {
+	s.RegisterService(&RollDiceService_ServiceDesc, srv)
+}
+
+func _RollDiceService_Roll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RollDiceServiceServer).Roll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RollDiceService_Roll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RollDiceServiceServer).Roll(ctx, req.(*RollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RollDiceService_WatchRolls_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRollsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RollDiceServiceServer).WatchRolls(m, &rollDiceServiceWatchRollsServer{ServerStream: stream})
+}
+
+type RollDiceService_WatchRollsServer interface {
+	Send(*WatchRollsResponse) error
+	grpc.ServerStream
+}
+
+type rollDiceServiceWatchRollsServer struct {
+	grpc.ServerStream
+}
+
+func (x *rollDiceServiceWatchRollsServer) Send(m *WatchRollsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// RollDiceService_ServiceDesc is the grpc.ServiceDesc for RollDiceService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RollDiceService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "rolldice.v1.RollDiceService",
+	HandlerType: (*RollDiceServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Roll",
+			Handler:    _RollDiceService_Roll_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchRolls",
+			Handler:       _RollDiceService_WatchRolls_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "rolldice/v1/rolldice.proto",
+}